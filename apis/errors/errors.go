@@ -10,13 +10,18 @@ import (
 type Kind string
 
 var (
-	KindValidation   Kind = "ValidationError"
-	KindInternal     Kind = "InternalError"
-	KindNotFound     Kind = "NotFoundError"
-	KindPrecondition Kind = "ConditionError"
-	KindPermission   Kind = "PermissionError"
-	KindRPC          Kind = "RPCError"
-	KindCustom       Kind = "CustomError"
+	KindValidation      Kind = "ValidationError"
+	KindInternal        Kind = "InternalError"
+	KindNotFound        Kind = "NotFoundError"
+	KindPrecondition    Kind = "ConditionError"
+	KindPermission      Kind = "PermissionError"
+	KindRPC             Kind = "RPCError"
+	KindCustom          Kind = "CustomError"
+	KindConflict        Kind = "ConflictError"
+	KindUnauthenticated Kind = "UnauthenticatedError"
+	KindRateLimit       Kind = "RateLimitError"
+	KindTimeout         Kind = "TimeoutError"
+	KindUnavailable     Kind = "UnavailableError"
 )
 
 func (k Kind) String() string {
@@ -47,6 +52,26 @@ type ErrorFactory interface {
 	// a specific resource.
 	PermissionDenied() Error
 
+	// Conflict should be used when a request conflicts with the current
+	// state of a resource, like when it was changed by another request.
+	Conflict(message string) Error
+
+	// Unauthenticated should be used when a client could not be
+	// authenticated.
+	Unauthenticated(err error) Error
+
+	// RateLimited should be used when a client exceeded the number of
+	// requests that it is allowed to make.
+	RateLimited() Error
+
+	// Timeout should be used when an operation did not finish in the time
+	// that it had.
+	Timeout(err error) Error
+
+	// Unavailable should be used when a resource or a dependency that the
+	// service needs is temporarily not available.
+	Unavailable(err error) Error
+
 	// Custom should be used by a service when none of the previous APIs are
 	// able to handle the error that occurred. It will be forward as an internal
 	// error.
@@ -0,0 +1,38 @@
+package health
+
+import (
+	"context"
+)
+
+// State is the service lifecycle state considered by its health checks.
+type State string
+
+var (
+	StateStarting State = "starting"
+	StateReady    State = "ready"
+	StateDraining State = "draining"
+)
+
+func (s State) String() string {
+	return string(s)
+}
+
+// Checker is the API that supported service types can use to answer health
+// check requests, combining the service lifecycle state with the health of
+// its features.
+type Checker interface {
+	// Liveness returns an error if the service is in a state that it can
+	// only recover by being restarted.
+	Liveness(ctx context.Context) error
+
+	// Readiness returns an error if the service is not able to handle new
+	// requests at the moment.
+	Readiness(ctx context.Context) error
+
+	// State gives the current service lifecycle state.
+	State() State
+
+	// OnStateChange registers a function that is called every time the
+	// service lifecycle state changes.
+	OnStateChange(f func(state State))
+}
@@ -4,6 +4,11 @@ import (
 	"context"
 )
 
+// ResponseErrorKey is the request user value key where the error returned by
+// a handler is stored, so that the HTTP service writes the response as an
+// RFC 7807 problem.
+const ResponseErrorKey = "handler-response-error"
+
 type ServiceAPI interface {
 	// AddResponseHeader adds a new header entry for the handler's response.
 	AddResponseHeader(ctx context.Context, key, value string)
@@ -11,3 +16,10 @@ type ServiceAPI interface {
 	// SetResponseCode sets a custom response code for the handler's response.
 	SetResponseCode(ctx context.Context, code int)
 }
+
+// ResponseErrorSetter is an optional behavior of the ServiceAPI to set the
+// error returned by a handler, so that the response is written as an RFC 7807
+// problem by the HTTP service.
+type ResponseErrorSetter interface {
+	SetResponseError(ctx context.Context, err error)
+}
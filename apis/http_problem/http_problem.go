@@ -0,0 +1,27 @@
+package http_problem
+
+import "context"
+
+// Problem is an RFC 7807 problem details object, describing an error returned
+// by an HTTP handler.
+type Problem struct {
+	Type     string `json:"type,omitempty"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	// Extension members with the framework error information.
+	Code        int32  `json:"code"`
+	Kind        string `json:"kind"`
+	ServiceName string `json:"service_name,omitempty"`
+}
+
+// Renderer is a behavior that an HTTP problem renderer feature (plugin) must
+// implement to customise how errors are written by the HTTP service
+// implementation.
+type Renderer interface {
+	// Render must write the response of the handler error err, already
+	// converted into problem, into ctx, which is the request context.
+	Render(ctx context.Context, problem *Problem, err error)
+}
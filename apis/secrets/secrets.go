@@ -0,0 +1,15 @@
+package secrets
+
+import (
+	"context"
+)
+
+// Provider is an interface that a plugin should implement to provide secret
+// values for a service, which can reference them inside the 'service.toml'
+// file using the notation secret://<name> or through the 'secret' option of
+// the env tag.
+type Provider interface {
+	// Secret retrieves the value of a secret by its name. It must return
+	// false, without an error, when the provider does not have the secret.
+	Secret(ctx context.Context, name string) (string, bool, error)
+}
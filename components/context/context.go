@@ -15,6 +15,8 @@ const (
 	contextKeyName = "service-context-"
 )
 
+type serviceContextKey struct{}
+
 // ServiceContext is an object that is stored inside a service RPC/HTTP handler
 // context.Context in order to provide information at all source levels, such
 // as, the logger.
@@ -70,8 +72,18 @@ func AppendValue(ctx context.Context, key, value string) context.Context {
 	return metadata.AppendToOutgoingContext(ctx, fmt.Sprintf("%s%s", contextKeyName, key), value)
 }
 
+// NewContext returns a new context carrying svcCtx, so that it does not need
+// to be rebuilt from the incoming metadata by FromContext.
+func NewContext(ctx context.Context, svcCtx *ServiceContext) context.Context {
+	return context.WithValue(ctx, serviceContextKey{}, svcCtx)
+}
+
 // FromContext retrieves a ServiceContext from the current context.
 func FromContext(ctx context.Context) (*ServiceContext, bool) {
+	if svcCtx, ok := ctx.Value(serviceContextKey{}).(*ServiceContext); ok {
+		return svcCtx, true
+	}
+
 	// Notice that we are reading the IncomingContext here, because we want to
 	// retrieve the ServiceContext that someone is sending to us.
 	if md, ok := metadata.FromIncomingContext(ctx); ok {
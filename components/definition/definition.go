@@ -2,10 +2,13 @@ package definition
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/BurntSushi/toml"
 	"github.com/creasty/defaults"
 	"github.com/go-playground/validator/v10"
 
@@ -16,21 +19,34 @@ import (
 // all service information that will be used to initialize it as well as all
 // features it will have when executing.
 type Definitions struct {
-	Name     string                            `toml:"name" validate:"required"`
-	Types    []string                          `toml:"types" validate:"required,single_script,no_duplicated_service,dive,service_type"`
-	Version  string                            `toml:"version" validate:"required,version"`
-	Language string                            `toml:"language" validate:"required,oneof=go rust"`
-	Product  string                            `toml:"product" validate:"required"`
-	Envs     []string                          `toml:"envs,omitempty" validate:"dive,ascii,uppercase"`
-	Features Features                          `toml:"features,omitempty"`
-	Log      Log                               `toml:"log,omitempty"`
-	Tests    Tests                             `toml:"tests"`
-	Service  map[string]interface{}            `toml:"service,omitempty"`
-	Clients  map[string]GrpcClient             `toml:"clients,omitempty"`
-	Services map[string]map[string]interface{} `toml:"services,omitempty"`
+	Name         string                            `toml:"name" validate:"required"`
+	Types        []string                          `toml:"types" validate:"required,single_script,no_duplicated_service,dive,service_type"`
+	Version      string                            `toml:"version" validate:"required,version"`
+	Language     string                            `toml:"language" validate:"required,oneof=go rust"`
+	Product      string                            `toml:"product" validate:"required"`
+	Envs         []string                          `toml:"envs,omitempty" validate:"dive,ascii,uppercase"`
+	Features     Features                          `toml:"features,omitempty"`
+	Log          Log                               `toml:"log,omitempty"`
+	Tests        Tests                             `toml:"tests"`
+	Shutdown     Shutdown                          `toml:"shutdown,omitempty"`
+	Listener     Listener                          `toml:"listener,omitempty"`
+	Validation   Validation                        `toml:"validation,omitempty"`
+	Environments map[string]DeploymentTraits       `toml:"environments,omitempty" validate:"dive"`
+	Service      map[string]interface{}            `toml:"service,omitempty"`
+	Clients      map[string]GrpcClient             `toml:"clients,omitempty"`
+	Services     map[string]map[string]interface{} `toml:"services,omitempty"`
 
 	supportedServiceTypes []string
 	externalServices      map[string]ExternalServiceEntry
+	source                []byte
+	tree                  map[string]interface{}
+	rawTree               map[string]interface{}
+	layers                []*Overlay
+	keys                  []toml.Key
+	undecoded             []toml.Key
+	sectionsUndecoded     map[string][]toml.Key
+	parseOptions          *parseOptions
+	missingEnvs           error
 }
 
 type Log struct {
@@ -73,9 +89,52 @@ type ExternalServiceEntry interface {
 	Validate() error
 }
 
-// Tests gathers unit tests related options.
+// Tests gathers unit tests related options. By default, in tests, a service
+// does not execute its lifecycle hooks, does not couple its gRPC clients and
+// only writes fatal log messages.
 type Tests struct {
+	// ExecuteLifecycle enables the service lifecycle hooks.
 	ExecuteLifecycle bool `toml:"execute_lifecycle"`
+
+	// CoupleClients enables the connection with the service gRPC clients.
+	CoupleClients bool `toml:"couple_clients,omitempty"`
+
+	// EnableLogs enables log messages of all levels.
+	EnableLogs bool `toml:"enable_logs,omitempty"`
+}
+
+// Shutdown gathers options related to how the service finishes its execution
+// when it receives a termination signal.
+type Shutdown struct {
+	// Timeout is the maximum time that the service waits for its servers to
+	// finish their in-flight requests before forcing them to stop.
+	Timeout time.Duration `toml:"timeout,omitempty" default:"30s" validate:"gte=0"`
+
+	// PreStopDelay is the time that the service waits, after announcing that
+	// it is not serving anymore, before stopping its servers. It gives load
+	// balancers time to remove the service from their pools.
+	PreStopDelay time.Duration `toml:"pre_stop_delay,omitempty" validate:"gte=0"`
+}
+
+// Listener gathers options related to how the service servers listen for
+// connections.
+type Listener struct {
+	// Shared makes the gRPC and the HTTP services share a single listener,
+	// using the port of the first one declared. Connections are routed to
+	// each server by their protocol.
+	Shared bool `toml:"shared,omitempty"`
+}
+
+// Validation gathers options related to how the definitions are validated.
+type Validation struct {
+	// Strict enables reporting every key that is not used by the framework
+	// nor by any of the service features and services. It fails the service
+	// startup in production-like environments and only warns otherwise.
+	Strict bool `toml:"strict,omitempty"`
+
+	// CheckVersion enables a warning when the service binary was built from
+	// a module version different from the one in the definitions.
+	CheckVersion bool `toml:"check_version,omitempty"`
 }
 
 // New creates a new Definitions structure initializing the service
@@ -95,7 +154,8 @@ func New() (*Definitions, error) {
 // Validate validates if all data loaded from the service definitions is
 // correct.
 //
-// It also validates external services and external features custom definitions.
+// It also validates external services and external features custom definitions,
+// reporting all errors found together.
 func (d *Definitions) Validate() error {
 	validate := validator.New()
 
@@ -116,25 +176,30 @@ func (d *Definitions) Validate() error {
 	}
 
 	ctx := context.Background()
-	ctx = context.WithValue(ctx, serviceTypeCtx{}, d.supportedServiceTypes)
+	ctx = context.WithValue(ctx, serviceTypeCtx{}, d.serviceTypes())
 
+	var errs []error
 	if err := validate.StructCtx(ctx, d); err != nil {
-		return err
+		errs = append(errs, err)
 	}
 
-	for _, svc := range d.externalServices {
+	if d.Listener.Shared && (!d.IsServiceType(ServiceType_gRPC) || !d.IsServiceType(ServiceType_HTTP)) {
+		errs = append(errs, errors.New("a shared listener requires both 'grpc' and 'http' service types"))
+	}
+
+	for name, svc := range d.externalServices {
 		if err := svc.Validate(); err != nil {
-			return err
+			errs = append(errs, fmt.Errorf("invalid service '%s' definitions: %w", name, err))
 		}
 	}
 
-	for _, f := range d.Features.externalFeatures {
+	for name, f := range d.Features.externalFeatures {
 		if err := f.Validate(); err != nil {
-			return err
+			errs = append(errs, fmt.Errorf("invalid feature '%s' definitions: %w", name, err))
 		}
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
 
 // IsServiceType checks if the current service definitions is of a specific
@@ -165,33 +230,95 @@ func (d *Definitions) ServiceTypesAsString() string {
 }
 
 // ServiceTypes gives back all service types found inside the service definitions.
+// When a type has more than one instance, the port of its first one is used.
 func (d *Definitions) ServiceTypes() map[ServiceType]service.ServerPort {
 	services := make(map[ServiceType]service.ServerPort)
 
-	for _, serviceType := range d.Types {
-		t, p := splitTypeAndPort(serviceType)
+	for _, instance := range d.ServiceInstances() {
+		if _, ok := services[instance.Type]; !ok {
+			services[instance.Type] = instance.Port
+		}
+	}
+
+	return services
+}
+
+// ServiceInstance is an entry of the service types list, i.e., a server that
+// the service executes.
+type ServiceInstance struct {
+	Type ServiceType
+
+	// Name is the instance name, empty when it was not declared.
+	Name string
+
+	// Port is the instance server port, where 0 means that any free port
+	// can be used. It is only valid when HasPort is true.
+	Port    service.ServerPort
+	HasPort bool
+
+	// Socket is the path of the unix socket where the instance listens,
+	// when declared with the notation 'type:unix:///path.sock'.
+	Socket string
+}
+
+// Key gives the instance identification, using the notation 'type:name', or
+// only the type name for unnamed instances.
+func (s ServiceInstance) Key() string {
+	if s.Name == "" {
+		return s.Type.String()
+	}
 
-		var (
-			serviceType = CreateServiceType(t)
-			port        = service.ServerPort(p)
-		)
+	return s.Type.String() + ":" + s.Name
+}
 
-		services[serviceType] = port
+// ServiceInstances gives back all service instances found inside the service
+// definitions, in the same order they were declared.
+func (d *Definitions) ServiceInstances() []ServiceInstance {
+	instances := make([]ServiceInstance, len(d.Types))
+	for i, serviceType := range d.Types {
+		instances[i] = parseServiceInstance(serviceType)
 	}
 
-	return services
+	return instances
 }
 
-func splitTypeAndPort(serviceType string) (string, int32) {
+// socketScheme is the prefix of unix socket addresses inside service types
+// entries.
+const socketScheme = "unix://"
+
+// parseServiceInstance parses a service types entry, which can use the
+// notations 'type', 'type:port', 'type:name', 'type:name:port',
+// 'type:unix:///path.sock' or 'type:name:unix:///path.sock'.
+func parseServiceInstance(serviceType string) ServiceInstance {
+	var instance ServiceInstance
+
+	if entry, socket, ok := strings.Cut(serviceType, ":"+socketScheme); ok {
+		serviceType = entry
+		instance.Socket = socket
+	}
+
 	parts := strings.Split(serviceType, ":")
+	instance.Type = CreateServiceType(parts[0])
+
+	switch len(parts) {
+	case 2:
+		// Ignores the error since the Validate was already called.
+		if p, err := strconv.ParseInt(parts[1], 10, 32); err == nil {
+			instance.Port = service.ServerPort(p)
+			instance.HasPort = true
+			break
+		}
+
+		instance.Name = parts[1]
 
-	if len(parts) == 1 {
-		return serviceType, int32(0)
+	case 3:
+		p, _ := strconv.ParseInt(parts[2], 10, 32)
+		instance.Name = parts[1]
+		instance.Port = service.ServerPort(p)
+		instance.HasPort = true
 	}
 
-	// Ignores the error since the Validate was already called.
-	p, _ := strconv.ParseInt(parts[1], 10, 32)
-	return parts[0], int32(p)
+	return instance
 }
 
 // AddExternalFeatureDefinitions adds definitions from external features into
@@ -238,11 +365,22 @@ func (d *Definitions) AddSupportedServiceType(name string) {
 		return false
 	}
 
-	if !isIn(name, d.supportedServiceTypes) {
-		d.supportedServiceTypes = append(d.supportedServiceTypes, name)
+	if !isIn(name, d.serviceTypes()) {
+		d.supportedServiceTypes = append(d.serviceTypes(), name)
 	}
 }
 
+// serviceTypes gives all service types supported by the definitions. It
+// always includes the framework's services, even if the Definitions was not
+// created using New.
+func (d *Definitions) serviceTypes() []string {
+	if d.supportedServiceTypes == nil {
+		return SupportedServiceTypes()
+	}
+
+	return d.supportedServiceTypes
+}
+
 // ExternalServiceDefinitions retrieves definitions from an external service
 // previously added into the Definitions.
 func (d *Definitions) ExternalServiceDefinitions(name string) (ExternalServiceEntry, error) {
@@ -254,6 +392,22 @@ func (d *Definitions) ExternalServiceDefinitions(name string) (ExternalServiceEn
 	return v, nil
 }
 
+// DeploymentTraits gives the traits of a deployment environment, by its name,
+// which must be one of the framework environments (prod, dev, local and test)
+// or declared inside the [environments] section. Declared environments replace
+// the framework ones with the same name.
+func (d *Definitions) DeploymentTraits(name string) (DeploymentTraits, error) {
+	if traits, ok := d.Environments[name]; ok {
+		return traits, nil
+	}
+
+	if env := ServiceDeploy(0).FromString(name); env != ServiceDeploy_Unknown {
+		return env.Traits(), nil
+	}
+
+	return DeploymentTraits{}, fmt.Errorf("deployment environment '%s' must be declared inside the [environments] section", name)
+}
+
 // LoadService retrieves only definitions from a specific service type.
 func (d *Definitions) LoadService(serviceType ServiceType) (map[string]interface{}, bool) {
 	dd, ok := d.Services[serviceType.String()]
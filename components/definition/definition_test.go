@@ -3,8 +3,11 @@ package definition
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/somatech1/mikros/components/service"
 )
 
 func TestDefinitionsValidation(t *testing.T) {
@@ -181,6 +184,42 @@ port = 9192
 				a.Equal(1, len(defs.Clients))
 			},
 		},
+		{
+			Title: "succeed with custom shutdown settings",
+			TomlDefinitions: `
+name = "service_test"
+types = ["grpc"]
+version = "v0.1.0"
+language = "go"
+product = "SDS"
+
+[shutdown]
+timeout = "10s"
+pre_stop_delay = "5s"
+`,
+			DefsAssertion:  a.NotNil,
+			ErrorAssertion: a.NoError,
+			CustomAssertion: func(defs *Definitions) {
+				a.Equal(10*time.Second, defs.Shutdown.Timeout)
+				a.Equal(5*time.Second, defs.Shutdown.PreStopDelay)
+			},
+		},
+		{
+			Title: "should use default shutdown settings",
+			TomlDefinitions: `
+name = "service_test"
+types = ["grpc"]
+version = "v0.1.0"
+language = "go"
+product = "SDS"
+`,
+			DefsAssertion:  a.NotNil,
+			ErrorAssertion: a.NoError,
+			CustomAssertion: func(defs *Definitions) {
+				a.Equal(30*time.Second, defs.Shutdown.Timeout)
+				a.Equal(time.Duration(0), defs.Shutdown.PreStopDelay)
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -213,3 +252,149 @@ port = 9192
 		})
 	}
 }
+
+func TestDeploymentTraits(t *testing.T) {
+	a := assert.New(t)
+	defs, err := ParseBytes([]byte(`
+name = "example"
+types = ["grpc"]
+version = "v1.0.0"
+language = "go"
+product = "SDS"
+
+[environments.staging]
+production_like = true
+log_level = "info"
+
+[environments.dev]
+log_level = "debug"
+`))
+	a.NoError(err)
+	a.NoError(defs.Validate())
+
+	t.Run("should give declared environments traits", func(t *testing.T) {
+		traits, err := defs.DeploymentTraits("staging")
+		a.NoError(err)
+		a.True(traits.ProductionLike)
+		a.Equal("info", traits.LogLevel)
+
+		traits, err = defs.DeploymentTraits(ServiceDeploy_Development.String())
+		a.NoError(err)
+		a.False(traits.DebugEndpoints)
+		a.Equal("debug", traits.LogLevel)
+	})
+
+	t.Run("should give framework environments traits", func(t *testing.T) {
+		traits, err := defs.DeploymentTraits(ServiceDeploy_Production.String())
+		a.NoError(err)
+		a.True(traits.ProductionLike)
+
+		traits, err = defs.DeploymentTraits(ServiceDeploy_Local.String())
+		a.NoError(err)
+		a.True(traits.DebugEndpoints)
+	})
+
+	t.Run("should not accept unknown environments", func(t *testing.T) {
+		_, err := defs.DeploymentTraits("qa")
+		a.ErrorContains(err, "deployment environment 'qa' must be declared")
+	})
+
+	t.Run("should validate environments traits", func(t *testing.T) {
+		defs.Environments["qa"] = DeploymentTraits{LogLevel: "verbose"}
+		a.Error(defs.Validate())
+	})
+}
+
+func TestServiceInstances(t *testing.T) {
+	a := assert.New(t)
+	defs, err := ParseBytes([]byte(`
+name = "example"
+types = ["grpc:9000", "http:public:8080", "http:internal"]
+version = "v1.0.0"
+language = "go"
+product = "SDS"
+
+[services.http.public]
+disable_auth = true
+
+[services.http.internal]
+disable_atuh = true
+`))
+	a.NoError(err)
+	a.NoError(defs.Validate())
+
+	t.Run("should give instances in the declared order", func(t *testing.T) {
+		a.Equal([]ServiceInstance{
+			{Type: ServiceType_gRPC, Port: 9000, HasPort: true},
+			{Type: ServiceType_HTTP, Name: "public", Port: 8080, HasPort: true},
+			{Type: ServiceType_HTTP, Name: "internal"},
+		}, defs.ServiceInstances())
+		a.Equal("http:public", defs.ServiceInstances()[1].Key())
+		a.Equal(service.ServerPort(8080), defs.ServiceTypes()[ServiceType_HTTP])
+	})
+
+	t.Run("should decode instances sections", func(t *testing.T) {
+		var public, internal struct {
+			DisableAuth bool `toml:"disable_auth"`
+		}
+
+		section, err := defs.InstanceSection(defs.ServiceInstances()[1])
+		a.NoError(err)
+		a.NoError(section.Decode(&public))
+		a.True(public.DisableAuth)
+
+		section, err = defs.InstanceSection(defs.ServiceInstances()[2])
+		a.NoError(err)
+		a.NoError(section.Decode(&internal))
+		a.False(internal.DisableAuth)
+
+		var keys []string
+		for _, k := range defs.UnknownKeys() {
+			keys = append(keys, k.Key)
+		}
+		a.Equal([]string{"services.http.internal.disable_atuh"}, keys)
+	})
+
+	t.Run("should validate instances", func(t *testing.T) {
+		for _, types := range [][]string{
+			{"http:public", "http:public:8080"},
+			{"http", "http:8080"},
+			{"http:Public"},
+			{"http:public:port"},
+			{"http:public:8080:1"},
+		} {
+			defs.Types = types
+			a.Error(defs.Validate(), types)
+		}
+
+		defs.Types = []string{"http", "http:public"}
+		a.NoError(defs.Validate())
+	})
+
+	t.Run("should distinguish port 0 from undeclared ports", func(t *testing.T) {
+		defs.Types = []string{"grpc:0", "http:public"}
+		a.NoError(defs.Validate())
+		a.Equal([]ServiceInstance{
+			{Type: ServiceType_gRPC, HasPort: true},
+			{Type: ServiceType_HTTP, Name: "public"},
+		}, defs.ServiceInstances())
+	})
+
+	t.Run("should parse unix sockets", func(t *testing.T) {
+		defs.Types = []string{"grpc:unix:///run/grpc.sock", "http:public:unix://api.sock"}
+		a.NoError(defs.Validate())
+		a.Equal([]ServiceInstance{
+			{Type: ServiceType_gRPC, Socket: "/run/grpc.sock"},
+			{Type: ServiceType_HTTP, Name: "public", Socket: "api.sock"},
+		}, defs.ServiceInstances())
+
+		for _, types := range [][]string{
+			{"http:unix://"},
+			{"http:8080:unix:///run/api.sock"},
+			{"http:public:8080:unix:///run/api.sock"},
+		} {
+			defs.Types = types
+			a.Error(defs.Validate(), types)
+		}
+	})
+}
@@ -0,0 +1,110 @@
+package definition
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// interpolationPattern matches the notations ${VAR} and ${VAR:-default}. An
+// escaped notation, $${VAR}, is also matched so that it can be kept as a
+// literal ${VAR}.
+var interpolationPattern = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// interpolateTree gives a copy of a tree of raw values with all environment
+// variables notations replaced by their values in every string value. All
+// variables that are not set, and don't have a default value, are reported
+// together in a single error, along with the copy, where they keep their
+// notations.
+func interpolateTree(tree map[string]interface{}) (map[string]interface{}, error) {
+	var (
+		missing      = make(map[string][]string)
+		interpolated = replaceTreeStrings("", tree, func(key, s string) string {
+			return interpolateString(key, s, missing)
+		})
+	)
+
+	if len(missing) > 0 {
+		var entries []string
+		for name, keys := range missing {
+			entries = append(entries, fmt.Sprintf("'%s' (used by %s)", name, strings.Join(keys, ", ")))
+		}
+		sort.Strings(entries)
+
+		return interpolated, fmt.Errorf("environment variables referenced by the definitions must be set: %s", strings.Join(entries, ", "))
+	}
+
+	return interpolated, nil
+}
+
+// replaceTreeStrings gives a copy of a tree of raw values with every string
+// value replaced by the result of replace, which receives the value key
+// path.
+func replaceTreeStrings(path string, table map[string]interface{}, replace func(key, s string) string) map[string]interface{} {
+	replaced := make(map[string]interface{}, len(table))
+	for k, v := range table {
+		key := k
+		if path != "" {
+			key = path + "." + k
+		}
+
+		replaced[k] = replaceValueStrings(key, v, replace)
+	}
+
+	return replaced
+}
+
+func replaceValueStrings(key string, value interface{}, replace func(key, s string) string) interface{} {
+	switch v := value.(type) {
+	case string:
+		return replace(key, v)
+
+	case map[string]interface{}:
+		return replaceTreeStrings(key, v, replace)
+
+	case []map[string]interface{}:
+		values := make([]map[string]interface{}, len(v))
+		for i, e := range v {
+			values[i] = replaceTreeStrings(fmt.Sprintf("%s[%d]", key, i), e, replace)
+		}
+		return values
+
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i, e := range v {
+			values[i] = replaceValueStrings(fmt.Sprintf("%s[%d]", key, i), e, replace)
+		}
+		return values
+	}
+
+	return value
+}
+
+func interpolateString(key, s string, missing map[string][]string) string {
+	return interpolationPattern.ReplaceAllStringFunc(s, func(match string) string {
+		if strings.HasPrefix(match, "$$") {
+			return match[1:]
+		}
+
+		var (
+			groups            = interpolationPattern.FindStringSubmatch(match)
+			name              = groups[1]
+			hasDefault        = groups[2] != ""
+			defaultValue      = groups[3]
+			value, isDeclared = os.LookupEnv(name)
+		)
+
+		if isDeclared && value != "" {
+			return value
+		}
+
+		if hasDefault {
+			return defaultValue
+		}
+
+		missing[name] = append(missing[name], key)
+		return match
+	})
+}
@@ -0,0 +1,117 @@
+package definition
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Overlay is a TOML content that can be merged on top of the service
+// definitions, allowing it to change only part of them, for example, for a
+// specific deployment environment.
+type Overlay struct {
+	// Name identifies the overlay, usually by its file path.
+	Name string
+
+	// Data is the overlay TOML content.
+	Data []byte
+}
+
+// LoadOverlay loads an overlay from a file.
+func LoadOverlay(path string) (*Overlay, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Overlay{
+		Name: path,
+		Data: data,
+	}, nil
+}
+
+// Merge merges overlays on top of the definitions, in the order they are
+// given, and gives back new definitions with the result. The merge follows
+// these rules:
+//
+//   - tables (maps) are merged recursively, key by key, so an overlay only
+//     needs to declare the keys that it changes;
+//   - any other value, including arrays and arrays of tables, is replaced
+//     entirely by the overlay value, i.e., arrays are never concatenated;
+//   - a key declared with a different type in the overlay replaces the
+//     original one.
+func (d *Definitions) Merge(overlays ...*Overlay) (*Definitions, error) {
+	// Merges the trees before their environment variables are interpolated,
+	// so that it happens only once, with the final values.
+	tree := d.rawTree
+	if tree == nil {
+		t, err := d.Tree()
+		if err != nil {
+			return nil, err
+		}
+		tree = t
+	}
+
+	merged := mergeTrees(nil, tree)
+	for _, overlay := range overlays {
+		values := make(map[string]interface{})
+		if _, err := toml.Decode(string(overlay.Data), &values); err != nil {
+			return nil, fmt.Errorf("could not parse overlay '%s': %w", overlay.Name, err)
+		}
+
+		merged = mergeTrees(merged, values)
+	}
+
+	options := d.parseOptions
+	if options == nil {
+		options = &parseOptions{}
+	}
+
+	defs, err := fromTree(merged, options)
+	if err != nil {
+		return nil, err
+	}
+
+	defs.layers = append(append([]*Overlay{}, d.layers...), overlays...)
+	return defs, nil
+}
+
+// Layers gives the names of all contents that were merged to build the
+// definitions, in the order they were merged.
+func (d *Definitions) Layers() []string {
+	var names []string
+	for _, l := range d.layers {
+		names = append(names, l.Name)
+	}
+
+	return names
+}
+
+// mergeTrees merges src on top of dst, giving back the result without
+// changing any of them.
+func mergeTrees(dst, src map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(dst))
+	for k, v := range dst {
+		merged[k] = v
+	}
+
+	for k, v := range src {
+		srcTable, srcIsTable := v.(map[string]interface{})
+		dstTable, dstIsTable := merged[k].(map[string]interface{})
+
+		if srcIsTable && dstIsTable {
+			merged[k] = mergeTrees(dstTable, srcTable)
+			continue
+		}
+
+		if srcIsTable {
+			merged[k] = mergeTrees(nil, srcTable)
+			continue
+		}
+
+		merged[k] = v
+	}
+
+	return merged
+}
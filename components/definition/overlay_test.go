@@ -0,0 +1,91 @@
+package definition
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	a := assert.New(t)
+	base, err := ParseBytes([]byte(`
+name = "example"
+types = ["grpc", "http"]
+version = "v1.0.0"
+language = "go"
+product = "SDS"
+envs = ["REGION"]
+
+[log]
+level = "info"
+error_stacktrace = true
+
+[clients.contract]
+host = "localhost"
+port = 9192
+
+[features.custom]
+enabled = false
+hosts = ["a", "b"]
+`))
+	a.NoError(err)
+
+	t.Run("should merge overlays in order", func(t *testing.T) {
+		defs, err := base.Merge(
+			&Overlay{
+				Name: "service.prod.toml",
+				Data: []byte(`
+types = ["grpc"]
+
+[log]
+level = "warn"
+
+[clients.contract]
+host = "contract.prod"
+
+[features.custom]
+enabled = true
+hosts = ["c"]
+`),
+			},
+			&Overlay{
+				Name: "extra.toml",
+				Data: []byte(`
+[log]
+level = "error"
+`),
+			},
+		)
+		a.NoError(err)
+		a.NoError(defs.Validate())
+
+		// Arrays are replaced
+		a.Equal([]string{"grpc"}, defs.Types)
+		a.Equal([]string{"REGION"}, defs.Envs)
+
+		// Tables are merged
+		a.Equal("error", defs.Log.Level)
+		a.True(defs.Log.ErrorStacktrace)
+		a.Equal("contract.prod", defs.Clients["contract"].Host)
+		a.Equal(int32(9192), defs.Clients["contract"].Port)
+
+		section, err := defs.FeatureSection("custom")
+		a.NoError(err)
+		a.Equal(true, section.Values()["enabled"])
+		a.Equal([]interface{}{"c"}, section.Values()["hosts"])
+
+		a.Equal([]string{"service.toml", "service.prod.toml", "extra.toml"}, defs.Layers())
+	})
+
+	t.Run("should not change the original definitions", func(t *testing.T) {
+		_, err := base.Merge(&Overlay{Name: "overlay", Data: []byte(`[log]
+level = "debug"`)})
+		a.NoError(err)
+		a.Equal("info", base.Log.Level)
+	})
+
+	t.Run("should fail with an invalid overlay", func(t *testing.T) {
+		_, err := base.Merge(&Overlay{Name: "overlay", Data: []byte(`[log`)})
+		a.Error(err)
+	})
+}
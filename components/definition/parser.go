@@ -1,24 +1,126 @@
 package definition
 
 import (
+	"bytes"
+	"io"
+	"os"
+
 	"github.com/BurntSushi/toml"
 )
 
+// ParseOption is an optional behavior that can be used when parsing the
+// definitions.
+type ParseOption func(o *parseOptions)
+
+type parseOptions struct {
+	allowMissingEnvs bool
+}
+
+// WithMissingEnvs lets the definitions be parsed even if environment
+// variables referenced by them are not set, keeping their notations as
+// values. The variables that were not set are given by MissingEnvs. Merged
+// definitions keep this behavior.
+func WithMissingEnvs() ParseOption {
+	return func(o *parseOptions) {
+		o.allowMissingEnvs = true
+	}
+}
+
 // Parse is responsible for loading the service definitions file (service.toml)
 // into a proper Definitions structure.
-func Parse(path string) (*Definitions, error) {
+func Parse(path string, opts ...ParseOption) (*Definitions, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defs, err := ParseBytes(data, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	defs.layers[0].Name = path
+	return defs, nil
+}
+
+// ParseReader loads the service definitions from a reader, allowing them to
+// come from somewhere other than a file, such as an embedded content.
+func ParseReader(r io.Reader, opts ...ParseOption) (*Definitions, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseBytes(data, opts...)
+}
+
+// ParseBytes loads the service definitions from a TOML content.
+//
+// Every string value of the content can reference environment variables
+// using the notations ${VAR} or ${VAR:-default}, where the default value is
+// used when the variable is not set or is empty. A literal '${' can be kept
+// by escaping it as '$${'.
+func ParseBytes(data []byte, opts ...ParseOption) (*Definitions, error) {
+	tree := make(map[string]interface{})
+	if _, err := toml.Decode(string(data), &tree); err != nil {
+		return nil, err
+	}
+
+	options := &parseOptions{}
+	for _, o := range opts {
+		o(options)
+	}
+
+	defs, err := fromTree(tree, options)
+	if err != nil {
+		return nil, err
+	}
+
+	defs.layers = []*Overlay{{Name: "service.toml", Data: data}}
+	return defs, nil
+}
+
+// fromTree creates the Definitions from a tree of raw values, interpolating
+// environment variables into its string values before decoding them.
+func fromTree(raw map[string]interface{}, options *parseOptions) (*Definitions, error) {
+	tree, missingEnvs := interpolateTree(raw)
+	if missingEnvs != nil && !options.allowMissingEnvs {
+		return nil, missingEnvs
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(tree); err != nil {
+		return nil, err
+	}
+
 	defs, err := New()
 	if err != nil {
 		return nil, err
 	}
 
-	if _, err := toml.DecodeFile(path, &defs); err != nil {
+	md, err := toml.Decode(buf.String(), defs)
+	if err != nil {
 		return nil, err
 	}
 
+	defs.source = buf.Bytes()
+	defs.tree = tree
+	defs.rawTree = raw
+	defs.keys = md.Keys()
+	defs.undecoded = md.Undecoded()
+	defs.parseOptions = options
+	defs.missingEnvs = missingEnvs
+
 	return defs, nil
 }
 
+// MissingEnvs gives an error reporting every environment variable referenced
+// by the definitions that is not set, when they were parsed using
+// WithMissingEnvs, or nil if all of them are set.
+func (d *Definitions) MissingEnvs() error {
+	return d.missingEnvs
+}
+
 // ParseExternalDefinitions allows loading specific service definitions from its
 // file using a custom target. This provides external features (plugins) to load
 // their definitions from the same file into their own structures.
@@ -29,3 +131,41 @@ func ParseExternalDefinitions(path string, defs interface{}) error {
 
 	return nil
 }
+
+// DecodeExternal decodes the definitions, i.e., the 'service.toml' content
+// with its secrets resolved, into a custom target. It allows external features
+// and services to load their settings from the same source used by the
+// service, whether it was a file or not.
+func (d *Definitions) DecodeExternal(target interface{}) error {
+	tree, err := d.Tree()
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(tree); err != nil {
+		return err
+	}
+
+	if _, err := toml.Decode(buf.String(), target); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Source gives the TOML content that the definitions were loaded from. If
+// they were not loaded from a TOML content, their current values are encoded
+// instead. Secrets are always kept as their secret://<name> references.
+func (d *Definitions) Source() ([]byte, error) {
+	if d.source != nil {
+		return d.source, nil
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(d); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,62 @@
+package definition
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseReader(t *testing.T) {
+	a := assert.New(t)
+
+	t.Run("should load definitions and external settings from a reader", func(t *testing.T) {
+		defs, err := ParseReader(strings.NewReader(`
+name = "example"
+types = ["grpc"]
+version = "v1.0.0"
+language = "go"
+product = "SDS"
+
+[features.custom]
+enabled = true
+value = 42
+`))
+		a.NoError(err)
+		a.NoError(defs.Validate())
+
+		var custom struct {
+			Features struct {
+				Custom struct {
+					Enabled bool `toml:"enabled"`
+					Value   int  `toml:"value"`
+				} `toml:"custom"`
+			} `toml:"features"`
+		}
+
+		a.NoError(defs.DecodeExternal(&custom))
+		a.True(custom.Features.Custom.Enabled)
+		a.Equal(42, custom.Features.Custom.Value)
+	})
+
+	t.Run("should decode external settings from inline definitions", func(t *testing.T) {
+		defs, err := New()
+		a.NoError(err)
+
+		defs.Name = "example"
+		defs.Services = map[string]map[string]interface{}{
+			"custom": {"value": 42},
+		}
+
+		var custom struct {
+			Services struct {
+				Custom struct {
+					Value int `toml:"value"`
+				} `toml:"custom"`
+			} `toml:"services"`
+		}
+
+		a.NoError(defs.DecodeExternal(&custom))
+		a.Equal(42, custom.Services.Custom.Value)
+	})
+}
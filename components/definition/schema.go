@@ -0,0 +1,253 @@
+package definition
+
+import (
+	"encoding"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	schemaVersion   = "https://json-schema.org/draft/2020-12/schema"
+	durationPattern = `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+)
+
+// Schema is a JSON Schema describing the service definitions.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// GenerateSchema creates the JSON Schema of the service definitions using
+// the Definitions 'toml', 'default' and 'validate' tags. The sections argument
+// adds the definitions types of features and services, where the key is the
+// section name, like 'features.name', 'services.name', 'services.type.name'
+// or 'service', and the value is a value of the type that the section is
+// decoded into.
+func GenerateSchema(sections map[string]interface{}) *Schema {
+	schema := schemaOf(reflect.TypeOf(Definitions{}))
+	schema.Schema = schemaVersion
+	schema.Title = "service.toml"
+
+	// Features are only known through their plugins.
+	schema.Properties["features"] = &Schema{
+		Type:                 "object",
+		Properties:           make(map[string]*Schema),
+		AdditionalProperties: true,
+	}
+
+	// Sections are added sorted, so that parent sections are always added
+	// before their inner ones.
+	names := make([]string, 0, len(sections))
+	for name := range sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := sections[name]
+		if value == nil {
+			continue
+		}
+
+		// Inner sections, like the ones from service instances, are added
+		// inside their parent section.
+		path := strings.Split(name, ".")
+		parent := schema
+		for _, key := range path[:len(path)-1] {
+			p, ok := parent.Properties[key]
+			if !ok {
+				p = &Schema{Type: "object", AdditionalProperties: true}
+				if parent.Properties == nil {
+					parent.Properties = make(map[string]*Schema)
+				}
+				parent.Properties[key] = p
+			}
+
+			parent = p
+		}
+
+		if parent.Properties == nil {
+			parent.Properties = make(map[string]*Schema)
+		}
+
+		parent.Properties[path[len(path)-1]] = schemaOf(reflect.TypeOf(value))
+	}
+
+	return schema
+}
+
+func schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t == durationType {
+		return &Schema{Type: "string", Pattern: durationPattern}
+	}
+
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: schemaOf(t.Elem())}
+
+	case reflect.Map:
+		s := &Schema{Type: "object", AdditionalProperties: true}
+		if t.Elem().Kind() != reflect.Interface {
+			s.AdditionalProperties = schemaOf(t.Elem())
+		}
+		return s
+
+	case reflect.Struct:
+		return structSchema(t)
+	}
+
+	// Interfaces accept anything.
+	return &Schema{}
+}
+
+func structSchema(t reflect.Type) *Schema {
+	schema := &Schema{
+		Type:                 "object",
+		Properties:           make(map[string]*Schema),
+		AdditionalProperties: false,
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag, hasTag := field.Tag.Lookup("toml")
+		if field.Anonymous && !hasTag {
+			// Embedded structs have their fields decoded as if they were
+			// declared by the parent.
+			if embedded := schemaOf(field.Type); embedded.Type == "object" {
+				for k, v := range embedded.Properties {
+					schema.Properties[k] = v
+				}
+				schema.Required = append(schema.Required, embedded.Required...)
+			}
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		s := schemaOf(field.Type)
+		if v, ok := field.Tag.Lookup("default"); ok {
+			s.Default = schemaValue(field.Type, v)
+		}
+
+		if applyValidateTag(s, field.Type, field.Tag.Get("validate")) {
+			schema.Required = append(schema.Required, name)
+		}
+
+		schema.Properties[name] = s
+	}
+
+	return schema
+}
+
+// applyValidateTag adds into a schema the rules of a 'validate' tag that it
+// supports, returning if the field is required.
+func applyValidateTag(s *Schema, t reflect.Type, tag string) bool {
+	var (
+		required bool
+		rules    = strings.Split(tag, ",")
+	)
+
+	for i, rule := range rules {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+
+		case "oneof":
+			for _, v := range strings.Fields(param) {
+				s.Enum = append(s.Enum, schemaValue(t, v))
+			}
+
+		case "gte", "min":
+			if s.Type == "integer" || s.Type == "number" {
+				if n, err := strconv.ParseFloat(param, 64); err == nil {
+					s.Minimum = &n
+				}
+			}
+
+		case "dive":
+			// Remaining rules are for the slice items.
+			if s.Items != nil {
+				applyValidateTag(s.Items, t.Elem(), strings.Join(rules[i+1:], ","))
+			}
+			return required
+		}
+	}
+
+	return required
+}
+
+// schemaValue converts a tag value to the JSON type of t.
+func schemaValue(t reflect.Type, v string) interface{} {
+	if t == durationType {
+		return v
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+
+	case reflect.Float32, reflect.Float64:
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	}
+
+	return v
+}
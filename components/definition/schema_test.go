@@ -0,0 +1,56 @@
+package definition
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type schemaFeatureExample struct {
+	FeatureEntry
+	Mode    string        `toml:"mode" validate:"required,oneof=fast safe"`
+	Retries int           `toml:"retries,omitempty" default:"3" validate:"gte=1"`
+	Timeout time.Duration `toml:"timeout,omitempty"`
+	Hosts   []string      `toml:"hosts,omitempty"`
+}
+
+func (s *schemaFeatureExample) Validate() error {
+	return nil
+}
+
+func TestGenerateSchema(t *testing.T) {
+	a := assert.New(t)
+	schema := GenerateSchema(map[string]interface{}{
+		"features.example": &schemaFeatureExample{},
+	})
+
+	t.Run("should describe the core definitions", func(t *testing.T) {
+		a.Equal("object", schema.Type)
+		a.Subset(schema.Required, []string{"name", "types", "version", "language", "product"})
+		a.Equal([]interface{}{"go", "rust"}, schema.Properties["language"].Enum)
+		a.Equal("array", schema.Properties["types"].Type)
+		a.Equal("string", schema.Properties["shutdown"].Properties["timeout"].Type)
+		a.Equal("30s", schema.Properties["shutdown"].Properties["timeout"].Default)
+		a.Equal("object", schema.Properties["clients"].AdditionalProperties.(*Schema).Type)
+	})
+
+	t.Run("should describe plugins definitions", func(t *testing.T) {
+		feature := schema.Properties["features"].Properties["example"]
+		a.NotNil(feature)
+		a.Equal("boolean", feature.Properties["enabled"].Type)
+		a.Equal([]string{"mode"}, feature.Required)
+		a.Equal([]interface{}{"fast", "safe"}, feature.Properties["mode"].Enum)
+		a.Equal(int64(3), feature.Properties["retries"].Default)
+		a.Equal(float64(1), *feature.Properties["retries"].Minimum)
+		a.Equal(durationPattern, feature.Properties["timeout"].Pattern)
+		a.Equal("string", feature.Properties["hosts"].Items.Type)
+	})
+
+	t.Run("should be encoded as JSON", func(t *testing.T) {
+		out, err := json.Marshal(schema)
+		a.NoError(err)
+		a.Contains(string(out), `"$schema":"https://json-schema.org/draft/2020-12/schema"`)
+	})
+}
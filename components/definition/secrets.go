@@ -0,0 +1,79 @@
+package definition
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	secretNotation = "secret://"
+)
+
+// ResolveSecrets replaces every string value of the definitions written
+// using the notation secret://<name> by the value that resolve gives for the
+// secret name and the key where it is used. All secrets that could not be
+// resolved are reported together in a single error.
+//
+// Resolved values are only used when decoding the definitions, they are never
+// part of their Source, which keeps the secret references.
+func (d *Definitions) ResolveSecrets(resolve func(key, name string) (string, error)) error {
+	tree, err := d.Tree()
+	if err != nil {
+		return err
+	}
+
+	var (
+		failures []string
+		found    bool
+	)
+
+	resolved := replaceTreeStrings("", tree, func(key, s string) string {
+		name, ok := strings.CutPrefix(s, secretNotation)
+		if !ok {
+			return s
+		}
+
+		found = true
+		value, err := resolve(key, name)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("'%s' (used by %s): %s", name, key, err))
+			return s
+		}
+
+		return value
+	})
+
+	if len(failures) > 0 {
+		sort.Strings(failures)
+		return fmt.Errorf("secrets referenced by the definitions could not be resolved: %s", strings.Join(failures, ", "))
+	}
+
+	if !found {
+		return nil
+	}
+
+	// Keeps the unresolved content as the definitions source before changing
+	// its values.
+	source, err := d.Source()
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(resolved); err != nil {
+		return err
+	}
+
+	if _, err := toml.Decode(buf.String(), d); err != nil {
+		return err
+	}
+
+	d.source = source
+	d.tree = resolved
+
+	return nil
+}
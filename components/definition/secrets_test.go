@@ -0,0 +1,81 @@
+package definition
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveSecrets(t *testing.T) {
+	a := assert.New(t)
+	secrets := map[string]string{
+		"db-password": "s3cr3t",
+		"api-key":     "k3y",
+	}
+
+	resolve := func(_, name string) (string, error) {
+		if v, ok := secrets[name]; ok {
+			return v, nil
+		}
+
+		return "", errors.New("not found")
+	}
+
+	t.Run("should resolve secrets without exposing them in the source", func(t *testing.T) {
+		defs, err := ParseBytes([]byte(`
+name = "example"
+types = ["grpc"]
+version = "v1.0.0"
+language = "go"
+product = "SDS"
+
+[service]
+api_key = "secret://api-key"
+
+[features.database]
+password = "secret://db-password"
+user = "admin"
+`))
+		a.NoError(err)
+
+		var keys []string
+		a.NoError(defs.ResolveSecrets(func(key, name string) (string, error) {
+			keys = append(keys, key)
+			return resolve(key, name)
+		}))
+		a.ElementsMatch([]string{"service.api_key", "features.database.password"}, keys)
+
+		a.Equal("k3y", defs.Service["api_key"])
+
+		section, err := defs.FeatureSection("database")
+		a.NoError(err)
+		a.Equal("s3cr3t", section.Values()["password"])
+		a.Equal("admin", section.Values()["user"])
+
+		source, err := defs.Source()
+		a.NoError(err)
+		a.Contains(string(source), "secret://db-password")
+		a.NotContains(string(source), "s3cr3t")
+		a.NotContains(string(source), "k3y")
+	})
+
+	t.Run("should report all secrets that could not be resolved", func(t *testing.T) {
+		defs, err := ParseBytes([]byte(`
+name = "example"
+types = ["grpc"]
+version = "v1.0.0"
+language = "go"
+product = "SDS"
+
+[service]
+token = "secret://token"
+key = "secret://key"
+`))
+		a.NoError(err)
+
+		err = defs.ResolveSecrets(resolve)
+		a.ErrorContains(err, "'token' (used by service.token): not found")
+		a.ErrorContains(err, "'key' (used by service.key): not found")
+	})
+}
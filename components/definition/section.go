@@ -0,0 +1,158 @@
+package definition
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/creasty/defaults"
+	"github.com/go-playground/validator/v10"
+)
+
+// Section is a subtree of the service definitions, such as a [features.<name>]
+// or a [services.<name>] object, already loaded from its source.
+type Section struct {
+	name     string
+	values   map[string]interface{}
+	defs     *Definitions
+	typeOnly bool
+	target   interface{}
+}
+
+// NewTypeSection creates a section without values, only used to discover
+// the type that a plugin decodes its definitions into. Decoding it only sets
+// the target default values, and the target is kept by the section.
+func NewTypeSection(name string) *Section {
+	return &Section{
+		name:     name,
+		typeOnly: true,
+	}
+}
+
+// Name gives the section full name inside the definitions, like
+// 'features.name'.
+func (s *Section) Name() string {
+	return s.name
+}
+
+// Target gives the last target that the section was decoded into.
+func (s *Section) Target() interface{} {
+	return s.target
+}
+
+// IsDefined returns if the section was declared inside the definitions.
+func (s *Section) IsDefined() bool {
+	return s.values != nil
+}
+
+// Values gives the section raw values.
+func (s *Section) Values() map[string]interface{} {
+	return s.values
+}
+
+// Decode decodes the section into target, which must be a pointer to a
+// struct using 'toml' tags. Before decoding, target receives its default
+// values through 'default' tags and, after that, it is validated using its
+// 'validate' tags.
+func (s *Section) Decode(target interface{}) error {
+	if t := reflect.TypeOf(target); t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("'%s' definitions target must be a pointer to a struct", s.name)
+	}
+
+	if err := defaults.Set(target); err != nil {
+		return fmt.Errorf("could not set '%s' definitions default values: %w", s.name, err)
+	}
+
+	s.target = target
+	if s.typeOnly {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(s.values); err != nil {
+		return err
+	}
+
+	md, err := toml.Decode(buf.String(), target)
+	if err != nil {
+		return fmt.Errorf("could not decode '%s' definitions: %w", s.name, err)
+	}
+
+	if s.defs != nil {
+		s.defs.setSectionUndecodedKeys(s.name, md.Undecoded())
+	}
+
+	if err := validator.New().Struct(target); err != nil {
+		return fmt.Errorf("invalid '%s' definitions: %w", s.name, err)
+	}
+
+	return nil
+}
+
+// FeatureSection gives the [features.<name>] section of the definitions.
+func (d *Definitions) FeatureSection(name string) (*Section, error) {
+	return d.section("features", name)
+}
+
+// ServiceSection gives the [services.<name>] section of the definitions.
+func (d *Definitions) ServiceSection(name string) (*Section, error) {
+	return d.section("services", name)
+}
+
+// InstanceSection gives the settings section of a service instance, which is
+// [services.<type>.<name>] for named instances and [services.<type>]
+// otherwise.
+func (d *Definitions) InstanceSection(instance ServiceInstance) (*Section, error) {
+	if instance.Name == "" {
+		return d.ServiceSection(instance.Type.String())
+	}
+
+	return d.section("services", instance.Type.String(), instance.Name)
+}
+
+// CustomSection gives the [service] section of the definitions, which holds
+// the service custom settings.
+func (d *Definitions) CustomSection() (*Section, error) {
+	return d.section("service")
+}
+
+func (d *Definitions) section(path ...string) (*Section, error) {
+	tree, err := d.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	values := tree
+	for _, key := range path {
+		v, _ := values[key].(map[string]interface{})
+		values = v
+	}
+
+	return &Section{
+		name:   strings.Join(path, "."),
+		values: values,
+		defs:   d,
+	}, nil
+}
+
+// Tree gives the definitions as a tree of raw values, as they were loaded
+// from their source, with their secrets already resolved.
+func (d *Definitions) Tree() (map[string]interface{}, error) {
+	if d.tree != nil {
+		return d.tree, nil
+	}
+
+	source, err := d.Source()
+	if err != nil {
+		return nil, err
+	}
+
+	tree := make(map[string]interface{})
+	if _, err := toml.Decode(string(source), &tree); err != nil {
+		return nil, err
+	}
+
+	return tree, nil
+}
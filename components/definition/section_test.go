@@ -0,0 +1,141 @@
+package definition
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type customFeatureDefinitions struct {
+	FeatureEntry
+	Host    string `toml:"host" validate:"required"`
+	Retries int    `toml:"retries" default:"3" validate:"gte=0"`
+}
+
+func TestSectionDecode(t *testing.T) {
+	a := assert.New(t)
+	defs, err := ParseBytes([]byte(`
+name = "example"
+types = ["grpc"]
+version = "v1.0.0"
+language = "go"
+product = "SDS"
+
+[features.custom]
+enabled = true
+host = "localhost"
+
+[features.invalid]
+retries = -1
+
+[services.custom]
+value = 42
+`))
+	a.NoError(err)
+
+	t.Run("should decode a section applying default values", func(t *testing.T) {
+		section, err := defs.FeatureSection("custom")
+		a.NoError(err)
+		a.True(section.IsDefined())
+		a.Equal("features.custom", section.Name())
+
+		var custom customFeatureDefinitions
+		a.NoError(section.Decode(&custom))
+		a.True(custom.IsEnabled())
+		a.Equal("localhost", custom.Host)
+		a.Equal(3, custom.Retries)
+	})
+
+	t.Run("should validate a decoded section", func(t *testing.T) {
+		section, err := defs.FeatureSection("invalid")
+		a.NoError(err)
+
+		var custom customFeatureDefinitions
+		err = section.Decode(&custom)
+		a.Error(err)
+		a.Contains(err.Error(), "features.invalid")
+	})
+
+	t.Run("should give an empty section when not declared", func(t *testing.T) {
+		section, err := defs.ServiceSection("unknown")
+		a.NoError(err)
+		a.False(section.IsDefined())
+
+		var custom struct {
+			Value int `toml:"value" default:"7"`
+		}
+		a.NoError(section.Decode(&custom))
+		a.Equal(7, custom.Value)
+	})
+
+	t.Run("should not accept a non struct target", func(t *testing.T) {
+		section, err := defs.ServiceSection("custom")
+		a.NoError(err)
+
+		var value int
+		a.Error(section.Decode(&value))
+	})
+
+	t.Run("should only keep the target of a type section", func(t *testing.T) {
+		section := NewTypeSection("features.custom")
+		a.False(section.IsDefined())
+
+		// Required values are not validated.
+		var custom customFeatureDefinitions
+		a.NoError(section.Decode(&custom))
+		a.Equal(3, custom.Retries)
+		a.Same(&custom, section.Target())
+	})
+}
+
+func TestCustomSection(t *testing.T) {
+	a := assert.New(t)
+	t.Setenv("MIKROS_TEST_BUCKET", "uploads")
+
+	defs, err := ParseBytes([]byte(`
+name = "example"
+types = ["grpc"]
+version = "v1.0.0"
+language = "go"
+product = "SDS"
+
+[service]
+bucket = "${MIKROS_TEST_BUCKET}"
+max_items = 20
+maxitems = 30
+`))
+	a.NoError(err)
+
+	type customDefinitions struct {
+		Bucket   string `toml:"bucket" validate:"required"`
+		MaxItems int    `toml:"max_items" validate:"lte=100"`
+		Region   string `toml:"region" default:"us-east-1"`
+	}
+
+	t.Run("should decode the service custom settings", func(t *testing.T) {
+		section, err := defs.CustomSection()
+		a.NoError(err)
+		a.Equal("service", section.Name())
+
+		var custom customDefinitions
+		a.NoError(section.Decode(&custom))
+		a.Equal("uploads", custom.Bucket)
+		a.Equal(20, custom.MaxItems)
+		a.Equal("us-east-1", custom.Region)
+
+		unknown := defs.UnknownKeys()
+		a.Len(unknown, 1)
+		a.Equal("service.maxitems", unknown[0].Key)
+	})
+
+	t.Run("should validate the service custom settings", func(t *testing.T) {
+		section, err := defs.CustomSection()
+		a.NoError(err)
+
+		var custom struct {
+			Bucket   string `toml:"bucket"`
+			MaxItems int    `toml:"max_items" validate:"lte=10"`
+		}
+		a.ErrorContains(section.Decode(&custom), "invalid 'service' definitions")
+	})
+}
@@ -0,0 +1,271 @@
+package definition
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// UnknownKey is a definitions key that is not used by the framework nor by
+// any of the service plugins.
+type UnknownKey struct {
+	// Key is the key full name, like 'features.tracing.enabeld'.
+	Key string
+
+	// Layer is the name of the last source, 'service.toml' or one of its
+	// overlays, that declares the key. It is empty when the definitions were
+	// not loaded from a source.
+	Layer string
+
+	// Line is the line of the layer where the key is declared, or 0 when it
+	// is not known.
+	Line int
+}
+
+func (u *UnknownKey) String() string {
+	if u.Layer == "" {
+		return u.Key
+	}
+	if u.Line == 0 {
+		return fmt.Sprintf("%s (%s)", u.Key, u.Layer)
+	}
+
+	return fmt.Sprintf("%s (%s:%d)", u.Key, u.Layer, u.Line)
+}
+
+// setSectionUndecodedKeys records the keys that were not used when a section
+// was decoded.
+func (d *Definitions) setSectionUndecodedKeys(name string, keys []toml.Key) {
+	if d.sectionsUndecoded == nil {
+		d.sectionsUndecoded = make(map[string][]toml.Key)
+	}
+
+	d.sectionsUndecoded[name] = keys
+}
+
+// UnknownKeys gives every key of the definitions that is not used, i.e., keys
+// that were not decoded by the core definitions neither by any Section of
+// [features.<name>] or [services.<name>], which are usually decoded by
+// plugins. Keys of the [service] section are only reported when it is
+// decoded through its Section. Sections that are used without being decoded through a Section
+// must be informed by their names, like 'features.name', so that they are
+// not reported.
+//
+// Only the outermost unknown key is reported, so an unknown table is reported
+// without its keys. Keys are given sorted by their names.
+func (d *Definitions) UnknownKeys(used ...string) []*UnknownKey {
+	var (
+		usedSections = make(map[string]bool)
+		undecoded    = make(map[string]bool)
+		reported     = make(map[string]bool)
+		layers       = d.layersMetadata()
+		unknown      []*UnknownKey
+	)
+
+	for _, name := range used {
+		usedSections[name] = true
+	}
+
+	for _, k := range d.undecoded {
+		undecoded[k.String()] = true
+	}
+
+	for _, k := range d.keys {
+		if !d.isUnknownKey(k, usedSections, undecoded) || hasReportedParent(k, reported) {
+			continue
+		}
+
+		reported[k.String()] = true
+		u := &UnknownKey{
+			Key: k.String(),
+		}
+		if i := layerDeclaring(k, layers); i >= 0 {
+			u.Layer = d.layers[i].Name
+			u.Line = layers[i].keyLine(k)
+		}
+
+		unknown = append(unknown, u)
+	}
+
+	sort.Slice(unknown, func(i, j int) bool {
+		return unknown[i].Key < unknown[j].Key
+	})
+
+	return unknown
+}
+
+func (d *Definitions) isUnknownKey(k toml.Key, usedSections, undecoded map[string]bool) bool {
+	switch k[0] {
+	case "features", "services":
+		if len(k) < 2 {
+			return false
+		}
+
+		// Keys are judged by the innermost section that contains them, since
+		// service instances have their sections inside their type section.
+		for i := len(k); i >= 2; i-- {
+			name := k[:i].String()
+			if usedSections[name] {
+				return false
+			}
+
+			if _, ok := d.sectionsUndecoded[name]; ok {
+				return d.isSectionUndecodedKey(name, k[i:])
+			}
+		}
+
+		// Nobody decoded the section, so all of it is unknown, unless it
+		// holds an inner section that was decoded.
+		return !d.hasInnerSection(k, usedSections)
+
+	case "service":
+		// Custom settings are only checked when they are decoded into a
+		// typed structure.
+		if len(k) < 2 {
+			return false
+		}
+
+		return d.isSectionUndecodedKey("service", k[1:])
+	}
+
+	return undecoded[k.String()]
+}
+
+func (d *Definitions) isSectionUndecodedKey(name string, k toml.Key) bool {
+	for _, u := range d.sectionsUndecoded[name] {
+		if u.String() == k.String() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (d *Definitions) hasInnerSection(k toml.Key, usedSections map[string]bool) bool {
+	prefix := k.String() + "."
+	for name := range d.sectionsUndecoded {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	for name := range usedSections {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasReportedParent(k toml.Key, reported map[string]bool) bool {
+	for i := 1; i < len(k); i++ {
+		if reported[k[:i].String()] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// layerMetadata holds the parsed content of a definitions layer.
+type layerMetadata struct {
+	md     toml.MetaData
+	values map[string]toml.Primitive
+}
+
+// errKeyPosition is used to retrieve the position of a key from the TOML
+// decoder, which only exposes it through its errors.
+var errKeyPosition = errors.New("key position")
+
+type keyPosition struct{}
+
+func (keyPosition) UnmarshalTOML(_ interface{}) error {
+	return errKeyPosition
+}
+
+// lookup gives the value of a key declared in the layer.
+func (l *layerMetadata) lookup(k toml.Key) (toml.Primitive, bool) {
+	value, ok := l.values[k[0]]
+	if !ok {
+		return toml.Primitive{}, false
+	}
+
+	for _, name := range k[1:] {
+		if value, ok = l.primitiveKey(value, name); !ok {
+			return toml.Primitive{}, false
+		}
+	}
+
+	return value, true
+}
+
+// keyLine gives the line where a key is declared in the layer, or 0 if it
+// cannot be found. The line is the one given by the TOML decoder, so any
+// TOML construct is supported.
+func (l *layerMetadata) keyLine(k toml.Key) int {
+	value, ok := l.lookup(k)
+	if !ok {
+		return 0
+	}
+
+	var pErr toml.ParseError
+	if err := l.md.PrimitiveDecode(value, keyPosition{}); errors.As(err, &pErr) {
+		return pErr.Position.Line
+	}
+
+	return 0
+}
+
+// primitiveKey gives the value of a key inside a table, or inside the first
+// table of an array of tables that has it. Arrays are tried first because
+// the decoder accepts them as empty tables.
+func (l *layerMetadata) primitiveKey(value toml.Primitive, name string) (toml.Primitive, bool) {
+	var tables []map[string]toml.Primitive
+	if err := l.md.PrimitiveDecode(value, &tables); err == nil {
+		for _, t := range tables {
+			if v, ok := t[name]; ok {
+				return v, true
+			}
+		}
+
+		return toml.Primitive{}, false
+	}
+
+	var table map[string]toml.Primitive
+	if err := l.md.PrimitiveDecode(value, &table); err != nil {
+		return toml.Primitive{}, false
+	}
+
+	v, ok := table[name]
+	return v, ok
+}
+
+// layerDeclaring gives the index of the last definitions layer that declares
+// a key, since it is the one that gives the key value, or -1 if none does.
+func layerDeclaring(k toml.Key, layers []*layerMetadata) int {
+	for i := len(layers) - 1; i >= 0; i-- {
+		if _, ok := layers[i].lookup(k); ok {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// layersMetadata gives the metadata of every definitions layer, in the same
+// order. Layers that cannot be parsed have empty metadata.
+func (d *Definitions) layersMetadata() []*layerMetadata {
+	layers := make([]*layerMetadata, len(d.layers))
+	for i, l := range d.layers {
+		layers[i] = &layerMetadata{}
+		if md, err := toml.Decode(string(l.Data), &layers[i].values); err == nil {
+			layers[i].md = md
+		}
+	}
+
+	return layers
+}
@@ -0,0 +1,165 @@
+package definition
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnknownKeys(t *testing.T) {
+	a := assert.New(t)
+
+	base, err := ParseBytes([]byte(`name = "example"
+types = ["grpc"]
+version = "v1.0.0"
+language = "go"
+product = "SDS"
+
+[log]
+levle = "debug"
+
+[features.tracing]
+enabeld = true
+
+[features.legacy]
+value = 1
+
+[features.unregistered]
+enabled = true
+
+[unknown_table]
+key = "value"
+`))
+	a.NoError(err)
+
+	defs, err := base.Merge(&Overlay{
+		Name: "service.prod.toml",
+		Data: []byte(`[services.http]
+port = 8080
+prot = 8081
+`),
+	})
+	a.NoError(err)
+
+	var tracing struct {
+		Enabled bool `toml:"enabled"`
+	}
+
+	section, err := defs.FeatureSection("tracing")
+	a.NoError(err)
+	a.NoError(section.Decode(&tracing))
+
+	var http struct {
+		Port int `toml:"port"`
+	}
+
+	section, err = defs.ServiceSection("http")
+	a.NoError(err)
+	a.NoError(section.Decode(&http))
+
+	var keys []string
+	for _, k := range defs.UnknownKeys("features.legacy") {
+		keys = append(keys, k.String())
+	}
+
+	a.Equal([]string{
+		"features.tracing.enabeld (service.toml:11)",
+		"features.unregistered (service.toml:16)",
+		"log.levle (service.toml:8)",
+		"services.http.prot (service.prod.toml:3)",
+		"unknown_table (service.toml:19)",
+	}, keys)
+
+	t.Run("should handle every TOML construct", func(t *testing.T) {
+		defs, err := ParseBytes([]byte(`name = "example"
+types = ["grpc"]
+version = "v1.0.0"
+language = "go"
+product = "SDS"
+envs = [
+  "A",
+  "B",
+]
+
+[service]
+description = """
+[fake_table]
+fake_key = 1
+"""
+endpoints = [
+  "a",
+  "b",
+]
+limits = { max = 10, mxa = 20 }
+cache.size = 10
+cache.tll = "1m"
+"quoted.key" = true
+
+[[service.rules]]
+name = "first"
+
+[[service.rules]]
+nmae = "second"
+`))
+		a.NoError(err)
+
+		var custom struct {
+			Description string   `toml:"description"`
+			Endpoints   []string `toml:"endpoints"`
+			Limits      struct {
+				Max int `toml:"max"`
+			} `toml:"limits"`
+			Cache struct {
+				Size int `toml:"size"`
+			} `toml:"cache"`
+			Rules []struct {
+				Name string `toml:"name"`
+			} `toml:"rules"`
+		}
+
+		section, err := defs.CustomSection()
+		a.NoError(err)
+		a.NoError(section.Decode(&custom))
+
+		var keys []string
+		for _, k := range defs.UnknownKeys() {
+			keys = append(keys, k.String())
+		}
+
+		a.Equal([]string{
+			"service.\"quoted.key\" (service.toml:23)",
+			"service.cache.tll (service.toml:22)",
+			"service.limits.mxa (service.toml:20)",
+			"service.rules.nmae (service.toml:29)",
+		}, keys)
+	})
+
+	t.Run("should give the line of the layer that declares the key", func(t *testing.T) {
+		base, err := ParseBytes([]byte(`name = "example"
+types = ["grpc"]
+version = "v1.0.0"
+language = "go"
+product = "SDS"
+
+[log]
+levle = "debug"
+lvel = "info"
+`))
+		a.NoError(err)
+
+		defs, err := base.Merge(&Overlay{
+			Name: "service.dev.toml",
+			Data: []byte(`
+
+[log]
+levle = "error"
+`),
+		})
+		a.NoError(err)
+
+		a.Equal([]*UnknownKey{
+			{Key: "log.levle", Layer: "service.dev.toml", Line: 4},
+			{Key: "log.lvel", Layer: "service.toml", Line: 9},
+		}, defs.UnknownKeys())
+	})
+}
@@ -65,6 +65,43 @@ func (e ServiceDeploy) FromString(in string) ServiceDeploy {
 	return ServiceDeploy_Unknown
 }
 
+// UnmarshalText sets the deployment environment from its name.
+func (e *ServiceDeploy) UnmarshalText(text []byte) error {
+	*e = ServiceDeploy(0).FromString(string(text))
+	return nil
+}
+
+// DeploymentTraits gathers the characteristics of a deployment environment
+// that change how the service and its features behave.
+type DeploymentTraits struct {
+	// ProductionLike marks the environment as one that must behave like
+	// production, being stricter with errors and with the information that
+	// it exposes.
+	ProductionLike bool `toml:"production_like,omitempty"`
+
+	// DebugEndpoints allows endpoints that expose internal information to be
+	// served.
+	DebugEndpoints bool `toml:"debug_endpoints,omitempty"`
+
+	// LogLevel is the log level used when one is not set in the [log]
+	// section.
+	LogLevel string `toml:"log_level,omitempty" validate:"omitempty,oneof=info debug error warn internal"`
+}
+
+// Traits gives the traits of the framework deployment environment. Custom
+// environments, which are ServiceDeploy_Unknown, have their traits declared
+// inside the [environments] section of the service definitions.
+func (e ServiceDeploy) Traits() DeploymentTraits {
+	switch e {
+	case ServiceDeploy_Production:
+		return DeploymentTraits{ProductionLike: true}
+	case ServiceDeploy_Test, ServiceDeploy_Development, ServiceDeploy_Local:
+		return DeploymentTraits{DebugEndpoints: true}
+	}
+
+	return DeploymentTraits{}
+}
+
 // SupportedServiceTypes gives a slice of all supported service types.
 func SupportedServiceTypes() []string {
 	var s []string
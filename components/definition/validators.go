@@ -14,15 +14,51 @@ func validateVersion(_ context.Context, fl validator.FieldLevel) bool {
 	return ValidateVersion(fl.Field().String())
 }
 
+// versionPattern matches semantic versions prefixed by 'v', like
+// 'v1.2.3-rc.1+build.5'. The short forms 'v1' and 'v1.2' are also accepted,
+// without pre-release and build metadata.
+var versionPattern = regexp.MustCompile(`^v(0|[1-9]\d*)(\.(0|[1-9]\d*)(\.(0|[1-9]\d*)` +
+	`(-((0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(\.(0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?` +
+	`(\+([0-9a-zA-Z-]+(\.[0-9a-zA-Z-]+)*))?)?)?$`)
+
 // ValidateVersion is a helper function to validate the version format used by
 // services.
 func ValidateVersion(input string) bool {
-	return regexp.MustCompile("^v[0-9]{1,2}(|[.][0-9]{1,2})(|[.][0-9]{1,2})$").MatchString(input)
+	return versionPattern.MatchString(input)
 }
 
+// EqualVersions checks if two versions are the same, considering missing
+// minor and patch numbers as 0 and ignoring build metadata.
+func EqualVersions(a, b string) bool {
+	normalize := func(v string) string {
+		v, _, _ = strings.Cut(v, "+")
+		core, preRelease, hasPreRelease := strings.Cut(v, "-")
+
+		parts := strings.Split(core, ".")
+		for len(parts) < 3 {
+			parts = append(parts, "0")
+		}
+
+		v = strings.Join(parts, ".")
+		if hasPreRelease {
+			v += "-" + preRelease
+		}
+
+		return v
+	}
+
+	return normalize(a) == normalize(b)
+}
+
+// instanceNamePattern matches the name of service instances.
+var instanceNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
 // validateServiceType validates if valid service type was used inside the
-// settings file. It also supports the notation 'type:port', where one can
-// set a custom server port for the specific service type.
+// settings file. It also supports the notations 'type:port', where one can
+// set a custom server port for the specific service type, 'type:name', to
+// name an instance of the service type, and 'type:name:port'. Instead of a
+// port, a unix socket can be used, like 'type:unix:///path.sock' or
+// 'type:name:unix:///path.sock'.
 func validateServiceType(ctx context.Context, fl validator.FieldLevel) bool {
 	if serviceType := fl.Field().String(); serviceType != "" {
 		supportedTypes, ok := ctx.Value(serviceTypeCtx{}).([]string)
@@ -30,13 +66,34 @@ func validateServiceType(ctx context.Context, fl validator.FieldLevel) bool {
 			return false
 		}
 
-		if strings.Contains(serviceType, ":") {
+		if entry, socket, ok := strings.Cut(serviceType, ":"+socketScheme); ok {
+			if socket == "" {
+				return false
+			}
+
+			// Only the instance name may come before the socket.
+			parts := strings.Split(entry, ":")
+			if len(parts) > 2 || (len(parts) == 2 && !instanceNamePattern.MatchString(parts[1])) {
+				return false
+			}
+
+			serviceType = parts[0]
+		} else if strings.Contains(serviceType, ":") {
 			parts := strings.Split(serviceType, ":")
-			if len(parts) > 1 {
-				// The server port was defined and, we must validate it.
-				if !validatePort(parts[1]) {
+			switch len(parts) {
+			case 2:
+				// The second part may be the server port or the instance name.
+				if !validatePort(parts[1]) && !instanceNamePattern.MatchString(parts[1]) {
 					return false
 				}
+
+			case 3:
+				if !instanceNamePattern.MatchString(parts[1]) || !validatePort(parts[2]) {
+					return false
+				}
+
+			default:
+				return false
 			}
 
 			serviceType = parts[0]
@@ -70,18 +127,19 @@ func ensureScriptTypeIsUnique(_ context.Context, fl validator.FieldLevel) bool {
 	return true
 }
 
-// checkDuplicatedServices validates if the list contains duplicated elements.
+// checkDuplicatedServices validates if the list contains duplicated elements,
+// i.e., more than one entry with the same service type and instance name.
 func checkDuplicatedServices(_ context.Context, fl validator.FieldLevel) bool {
 	if list, ok := fl.Field().Interface().([]string); ok {
 		types := make(map[string]bool)
 		for _, t := range list {
-			_, ok := types[t]
-			if !ok {
-				types[t] = true
-			}
-			if ok {
+			key := parseServiceInstance(t).Key()
+
+			if types[key] {
 				return false
 			}
+
+			types[key] = true
 		}
 	}
 
@@ -0,0 +1,46 @@
+package definition
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateVersion(t *testing.T) {
+	tests := []struct {
+		Version string
+		Valid   bool
+	}{
+		{Version: "v1", Valid: true},
+		{Version: "v1.2", Valid: true},
+		{Version: "v1.2.3", Valid: true},
+		{Version: "v1.100.0", Valid: true},
+		{Version: "v1.2.3-rc.1", Valid: true},
+		{Version: "v1.2.3+build.5", Valid: true},
+		{Version: "v1.2.3-alpha.1+build.5", Valid: true},
+		{Version: "v0.0.0-20240101120000-abcdef123456", Valid: true},
+		{Version: "1.2.3", Valid: false},
+		{Version: "v1.2-rc.1", Valid: false},
+		{Version: "v01.2.3", Valid: false},
+		{Version: "v1.2.3-", Valid: false},
+		{Version: "v1.2.3-rc..1", Valid: false},
+		{Version: "v1.2.3.4", Valid: false},
+		{Version: "5.1-alpha", Valid: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Version, func(t *testing.T) {
+			assert.Equal(t, test.Valid, ValidateVersion(test.Version))
+		})
+	}
+}
+
+func TestEqualVersions(t *testing.T) {
+	a := assert.New(t)
+	a.True(EqualVersions("v1.2.3", "v1.2.3"))
+	a.True(EqualVersions("v1.2", "v1.2.0"))
+	a.True(EqualVersions("v1", "v1.0.0+build.7"))
+	a.True(EqualVersions("v1.2.3-rc.1", "v1.2.3-rc.1+build.7"))
+	a.False(EqualVersions("v1.2.3", "v1.2.4"))
+	a.False(EqualVersions("v1.2.3", "v1.2.3-rc.1"))
+}
@@ -1,14 +1,17 @@
 package env
 
 import (
+	"context"
+	"encoding"
 	"errors"
 	"fmt"
 	"os"
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
-	"github.com/somatech1/mikros/components/definition"
+	secretsApi "github.com/somatech1/mikros/apis/secrets"
 	"github.com/somatech1/mikros/components/service"
 )
 
@@ -20,42 +23,139 @@ type Env[T any] struct {
 	varName string
 }
 
+// envLoader is the internal behavior that allows Env fields to be loaded
+// independently of their value type.
+type envLoader interface {
+	load(varName, value string) error
+}
+
 type envTag struct {
 	SkipField    bool
+	IsSecret     bool
+	IsRequired   bool
 	Name         string
 	DefaultValue string
 }
 
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	envLoaderType       = reflect.TypeOf((*envLoader)(nil)).Elem()
+)
+
+// LoadOption is an optional behavior that can be used by Load.
+type LoadOption func(o *loadOptions)
+
+type loadOptions struct {
+	ctx     context.Context
+	secrets secretsApi.Provider
+}
+
+// WithSecrets sets the provider used to load the fields tagged as secrets,
+// i.e., with the 'secret' option, using the tag name as the secret name.
+func WithSecrets(ctx context.Context, secrets secretsApi.Provider) LoadOption {
+	return func(o *loadOptions) {
+		o.ctx = ctx
+		o.secrets = secrets
+	}
+}
+
 // Load fills the structure env argument by loading environment variables
-// into it.
-func Load(serviceName service.Name, env interface{}) error {
+// into it. Every exported field must have an 'env' tag, with the variable name
+// and, optionally, the following options:
+//
+//   - default_value=<value>: the value used when the variable is not set;
+//   - required: fails if the variable is not set and has no default value;
+//   - secret: loads the field from the secrets provider (see WithSecrets);
+//   - skip: ignores the field.
+//
+// Supported field types are strings, booleans, all integer and float types,
+// time.Duration, encoding.TextUnmarshaler implementations and Env. Slices are
+// loaded from comma-separated values and maps from comma-separated key=value
+// pairs. A nested struct has its fields loaded using its tag name, if any,
+// as prefix of their variable names, like PREFIX_NAME.
+//
+// All errors found while loading the fields are reported together.
+func Load(serviceName service.Name, env interface{}, opts ...LoadOption) error {
+	valueOf := reflect.ValueOf(env)
+	if valueOf.Kind() != reflect.Pointer || valueOf.Elem().Kind() != reflect.Struct {
+		return errors.New("env must be a pointer to a struct")
+	}
+
+	options := &loadOptions{}
+	for _, o := range opts {
+		o(options)
+	}
+
+	return errors.Join(loadStruct(valueOf.Elem(), "", "", serviceName, options)...)
+}
+
+func loadStruct(valueOf reflect.Value, path, prefix string, serviceName service.Name, options *loadOptions) []error {
 	var (
-		typeOf  = reflect.TypeOf(env)
-		valueOf = reflect.ValueOf(env)
+		errs   []error
+		typeOf = valueOf.Type()
 	)
 
-	for i := 0; i < typeOf.Elem().NumField(); i++ {
-		typeField := typeOf.Elem().Field(i)
+	for i := 0; i < typeOf.NumField(); i++ {
+		typeField := typeOf.Field(i)
+		if !typeField.IsExported() {
+			continue
+		}
+
+		fieldPath := typeField.Name
+		if path != "" {
+			fieldPath = path + "." + typeField.Name
+		}
+
 		tag, err := parseFieldTag(typeField.Tag)
 		if err != nil {
-			return fmt.Errorf("'%s':%w", typeField.Name, err)
+			errs = append(errs, fmt.Errorf("'%s': %w", fieldPath, err))
+			continue
 		}
 
 		if tag.SkipField {
 			continue
 		}
 
-		fieldValue, err := loadFieldValue(typeField, tag, serviceName)
+		name := tag.Name
+		if prefix != "" && name != "" {
+			name = prefix + "_" + name
+		}
+
+		field := valueOf.Field(i)
+		if isNestedStruct(field) {
+			errs = append(errs, loadStruct(field, fieldPath, name, serviceName, options)...)
+			continue
+		}
+
+		v, err := loadValue(name, tag, serviceName, options)
 		if err != nil {
-			return err
+			errs = append(errs, fmt.Errorf("'%s': %w", fieldPath, err))
+			continue
 		}
 
-		ptr := reflect.New(fieldValue.Type())
-		ptr.Elem().Set(fieldValue)
-		valueOf.Elem().Field(i).Set(ptr.Elem())
+		if loader, ok := field.Addr().Interface().(envLoader); ok {
+			err = loader.load(name, v)
+		} else {
+			err = setValue(field, v)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("'%s': invalid value for '%s': %w", fieldPath, name, err))
+		}
 	}
 
-	return nil
+	return errs
+}
+
+// isNestedStruct checks if a field is a struct that must have its fields
+// loaded, instead of being loaded from a single value.
+func isNestedStruct(field reflect.Value) bool {
+	if field.Kind() != reflect.Struct {
+		return false
+	}
+
+	ptr := reflect.PointerTo(field.Type())
+	return !ptr.Implements(envLoaderType) && !ptr.Implements(textUnmarshalerType)
 }
 
 func parseFieldTag(tag reflect.StructTag) (*envTag, error) {
@@ -65,108 +165,161 @@ func parseFieldTag(tag reflect.StructTag) (*envTag, error) {
 	}
 
 	entries := strings.Split(t, ",")
-	if len(entries) == 0 {
-		return nil, errors.New("'env' tag cannot be empty")
-	}
-
 	parsedTag := &envTag{
 		Name: entries[0],
 	}
 
+	hasDefault := false
 	for _, entry := range entries[1:] {
-		parts := strings.Split(entry, "=")
+		parts := strings.SplitN(entry, "=", 2)
 		switch parts[0] {
 		case "default_value":
-			parsedTag.DefaultValue = parts[1]
+			if len(parts) == 2 {
+				parsedTag.DefaultValue = parts[1]
+			}
+			hasDefault = true
 		case "skip":
 			parsedTag.SkipField = true
+		case "secret":
+			parsedTag.IsSecret = true
+		case "required":
+			parsedTag.IsRequired = true
+		default:
+			// Default values of slices and maps have commas, so everything
+			// that is not an option belongs to it.
+			if !hasDefault {
+				return nil, fmt.Errorf("unsupported 'env' tag option '%s'", entry)
+			}
+			parsedTag.DefaultValue += "," + entry
 		}
 	}
 
 	return parsedTag, nil
 }
 
-func loadFieldValue(field reflect.StructField, tag *envTag, serviceName service.Name) (reflect.Value, error) {
-	switch field.Type.Kind() {
-	case reflect.Bool:
-		return loadBoolFieldValue(tag, serviceName)
-
-	case reflect.Int32:
-		return loadInt32FieldValue(field.Name, tag, serviceName)
-
-	case reflect.String:
-		return loadStringFieldValue(tag, serviceName)
+// loadValue retrieves the raw value of a field, from an environment variable
+// or, if it is a secret, from the secrets provider.
+func loadValue(name string, tag *envTag, serviceName service.Name, options *loadOptions) (string, error) {
+	if !tag.IsSecret {
+		v := getEnv(serviceName, name, tag.DefaultValue)
+		if v == "" && tag.IsRequired {
+			return "", fmt.Errorf("environment variable '%s' must be set", name)
+		}
 
-	case reflect.Struct:
-		return loadStructFieldValue(tag, serviceName, field.Type.String())
+		return v, nil
 	}
 
-	return reflect.Value{}, fmt.Errorf("unsupported type (%s) for field '%s'", field.Type.Kind().String(), field.Name)
-}
-
-func loadBoolFieldValue(tag *envTag, serviceName service.Name) (reflect.Value, error) {
-	v := getEnv(serviceName, tag.Name, tag.DefaultValue)
-	if v != "true" && v != "false" {
-		return reflect.Value{}, fmt.Errorf("unsupported value '%s' of bool field", v)
+	if options.secrets == nil {
+		return "", fmt.Errorf("no secrets provider available to load secret '%s'", name)
 	}
 
-	boolValue, err := strconv.ParseBool(v)
+	v, ok, err := options.secrets.Secret(options.ctx, name)
 	if err != nil {
-		return reflect.Value{}, err
+		return "", err
 	}
+	if !ok {
+		if tag.DefaultValue == "" {
+			return "", fmt.Errorf("secret '%s' not found", name)
+		}
 
-	return reflect.ValueOf(boolValue), nil
-}
+		v = tag.DefaultValue
+	}
 
-func loadInt32FieldValue(fieldName string, tag *envTag, serviceName service.Name) (reflect.Value, error) {
-	v := getEnv(serviceName, tag.Name, tag.DefaultValue)
+	return v, nil
+}
 
-	// Handle special cases
-	if fieldName == "DeploymentEnv" {
-		env := definition.ServiceDeploy.FromString(definition.ServiceDeploy(0), v)
-		return reflect.ValueOf(env), nil
+// setValue converts a raw value to the field type and sets it. Empty values
+// keep the field with its zero value.
+func setValue(field reflect.Value, v string) error {
+	if v == "" {
+		return nil
 	}
 
-	intValue, err := strconv.ParseInt(v, 10, 32)
-	if err != nil {
-		return reflect.Value{}, err
+	if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(v))
 	}
 
-	return reflect.ValueOf(int32(intValue)), nil
-}
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return err
+		}
 
-func loadStringFieldValue(tag *envTag, serviceName service.Name) (reflect.Value, error) {
-	v := getEnv(serviceName, tag.Name, tag.DefaultValue)
-	return reflect.ValueOf(v), nil
-}
+		field.SetInt(int64(d))
+		return nil
+	}
 
-func loadStructFieldValue(tag *envTag, serviceName service.Name, fieldType string) (reflect.Value, error) {
-	v := getEnv(serviceName, tag.Name, tag.DefaultValue)
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(v)
 
-	if strings.Contains(fieldType, "[string]") {
-		e := Env[string]{
-			value:   v,
-			varName: tag.Name,
+	case reflect.Bool:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
 		}
+		field.SetBool(b)
 
-		return reflect.ValueOf(e), nil
-	}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(v, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
 
-	if strings.Contains(fieldType, "[int32]") {
-		intValue, err := strconv.ParseInt(v, 10, 32)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(v, 10, field.Type().Bits())
 		if err != nil {
-			return reflect.Value{}, err
+			return err
 		}
+		field.SetUint(n)
 
-		e := Env[int32]{
-			value:   int32(intValue),
-			varName: tag.Name,
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(v, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+
+	case reflect.Slice:
+		entries := strings.Split(v, ",")
+		slice := reflect.MakeSlice(field.Type(), len(entries), len(entries))
+		for i, entry := range entries {
+			if err := setValue(slice.Index(i), strings.TrimSpace(entry)); err != nil {
+				return err
+			}
 		}
+		field.Set(slice)
+
+	case reflect.Map:
+		m := reflect.MakeMap(field.Type())
+		for _, entry := range strings.Split(v, ",") {
+			key, value, ok := strings.Cut(entry, "=")
+			if !ok {
+				return fmt.Errorf("invalid map entry '%s', it must use the key=value format", entry)
+			}
+
+			var (
+				k = reflect.New(field.Type().Key()).Elem()
+				e = reflect.New(field.Type().Elem()).Elem()
+			)
+
+			if err := setValue(k, strings.TrimSpace(key)); err != nil {
+				return err
+			}
+			if err := setValue(e, strings.TrimSpace(value)); err != nil {
+				return err
+			}
+
+			m.SetMapIndex(k, e)
+		}
+		field.Set(m)
 
-		return reflect.ValueOf(e), nil
+	default:
+		return fmt.Errorf("unsupported type '%s'", field.Type())
 	}
 
-	return reflect.Value{}, fmt.Errorf("unsupported Env '%v'", fieldType)
+	return nil
 }
 
 // getEnv is a helper function to load environment variables using priority key
@@ -193,6 +346,11 @@ func getEnvOrDefault(name string, defaultValue string) string {
 	return value
 }
 
+func (e *Env[T]) load(varName, value string) error {
+	e.varName = varName
+	return setValue(reflect.ValueOf(&e.value).Elem(), value)
+}
+
 func (e Env[T]) Value() T {
 	return e.value
 }
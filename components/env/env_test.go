@@ -1,9 +1,14 @@
 package env
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
 
 	"github.com/somatech1/mikros/components/definition"
 	"github.com/somatech1/mikros/components/service"
@@ -62,3 +67,106 @@ func TestLoad(t *testing.T) {
 		})
 	}
 }
+
+type secretsProvider map[string]string
+
+func (s secretsProvider) Secret(_ context.Context, name string) (string, bool, error) {
+	v, ok := s[name]
+	return v, ok, nil
+}
+
+type secretsExample struct {
+	Password Env[string] `env:"DB_PASSWORD,secret"`
+	Token    string      `env:"TOKEN,secret,default_value=none"`
+}
+
+func TestLoadSecrets(t *testing.T) {
+	a := assert.New(t)
+
+	t.Run("should load fields tagged as secrets from the provider", func(t *testing.T) {
+		var e secretsExample
+		err := Load(service.FromString("example"), &e, WithSecrets(context.Background(), secretsProvider{
+			"DB_PASSWORD": "s3cr3t",
+		}))
+		a.NoError(err)
+		a.Equal("s3cr3t", e.Password.Value())
+		a.Equal("none", e.Token)
+	})
+
+	t.Run("should fail without a secrets provider", func(t *testing.T) {
+		var e secretsExample
+		err := Load(service.FromString("example"), &e)
+		a.ErrorContains(err, "no secrets provider available")
+	})
+}
+
+type level string
+
+func (l *level) UnmarshalText(text []byte) error {
+	*l = level(strings.ToUpper(string(text)))
+	return nil
+}
+
+type databaseExample struct {
+	Host string `env:"HOST,default_value=localhost"`
+	Port uint16 `env:"PORT,required"`
+}
+
+type typesExample struct {
+	DeploymentEnv definition.ServiceDeploy `env:"TYPES_DEPLOY"`
+	Count         int                      `env:"TYPES_COUNT"`
+	Size          int64                    `env:"TYPES_SIZE"`
+	Ratio         float64                  `env:"TYPES_RATIO"`
+	Timeout       time.Duration            `env:"TYPES_TIMEOUT,default_value=5s"`
+	Hosts         []string                 `env:"TYPES_HOSTS,default_value=a,b"`
+	Ports         []int                    `env:"TYPES_PORTS"`
+	Labels        map[string]string        `env:"TYPES_LABELS"`
+	Level         level                    `env:"TYPES_LEVEL"`
+	Retries       Env[uint8]               `env:"TYPES_RETRIES"`
+	Database      databaseExample          `env:"TYPES_DATABASE"`
+}
+
+func TestLoadTypes(t *testing.T) {
+	a := assert.New(t)
+
+	t.Run("should load all supported types", func(t *testing.T) {
+		t.Setenv("TYPES_DEPLOY", "prod")
+		t.Setenv("TYPES_COUNT", "-3")
+		t.Setenv("TYPES_SIZE", "9000000000")
+		t.Setenv("TYPES_RATIO", "0.75")
+		t.Setenv("TYPES_PORTS", "80, 443")
+		t.Setenv("TYPES_LABELS", "team=core,tier=1")
+		t.Setenv("TYPES_LEVEL", "debug")
+		t.Setenv("TYPES_RETRIES", "3")
+		t.Setenv("TYPES_DATABASE_PORT", "5432")
+
+		var e typesExample
+		a.NoError(Load(service.FromString("example"), &e))
+		a.Equal(definition.ServiceDeploy_Production, e.DeploymentEnv)
+		a.Equal(-3, e.Count)
+		a.Equal(int64(9000000000), e.Size)
+		a.Equal(0.75, e.Ratio)
+		a.Equal(5*time.Second, e.Timeout)
+		a.Equal([]string{"a", "b"}, e.Hosts)
+		a.Equal([]int{80, 443}, e.Ports)
+		a.Equal(map[string]string{"team": "core", "tier": "1"}, e.Labels)
+		a.Equal(level("DEBUG"), e.Level)
+		a.Equal(uint8(3), e.Retries.Value())
+		a.Equal("TYPES_RETRIES", e.Retries.VarName())
+		a.Equal("localhost", e.Database.Host)
+		a.Equal(uint16(5432), e.Database.Port)
+	})
+
+	t.Run("should report all invalid fields together", func(t *testing.T) {
+		t.Setenv("TYPES_COUNT", "three")
+		t.Setenv("TYPES_TIMEOUT", "5 minutes")
+		t.Setenv("TYPES_LABELS", "team")
+
+		var e typesExample
+		err := Load(service.FromString("example"), &e)
+		a.ErrorContains(err, "'Count': invalid value for 'TYPES_COUNT'")
+		a.ErrorContains(err, "'Timeout': invalid value for 'TYPES_TIMEOUT'")
+		a.ErrorContains(err, "'Labels': invalid value for 'TYPES_LABELS'")
+		a.ErrorContains(err, "'Database.Port': environment variable 'TYPES_DATABASE_PORT' must be set")
+	})
+}
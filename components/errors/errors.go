@@ -70,8 +70,63 @@ func IsRPCError(err error) bool {
 	return false
 }
 
+// IsConflictError checks if an error is a framework Conflict error.
+func IsConflictError(err error) bool {
+	if e, ok := IsKnownError(err); ok {
+		return e.Kind == errorsApi.KindConflict
+	}
+
+	return false
+}
+
+// IsUnauthenticatedError checks if an error is a framework Unauthenticated error.
+func IsUnauthenticatedError(err error) bool {
+	if e, ok := IsKnownError(err); ok {
+		return e.Kind == errorsApi.KindUnauthenticated
+	}
+
+	return false
+}
+
+// IsRateLimitError checks if an error is a framework RateLimited error.
+func IsRateLimitError(err error) bool {
+	if e, ok := IsKnownError(err); ok {
+		return e.Kind == errorsApi.KindRateLimit
+	}
+
+	return false
+}
+
+// IsTimeoutError checks if an error is a framework Timeout error.
+func IsTimeoutError(err error) bool {
+	if e, ok := IsKnownError(err); ok {
+		return e.Kind == errorsApi.KindTimeout
+	}
+
+	return false
+}
+
+// IsUnavailableError checks if an error is a framework Unavailable error.
+func IsUnavailableError(err error) bool {
+	if e, ok := IsKnownError(err); ok {
+		return e.Kind == errorsApi.KindUnavailable
+	}
+
+	return false
+}
+
 func IsKnownError(err error) (*merrors.Error, bool) {
 	var e *merrors.Error
 	ok := errors.As(err, &e)
 	return e, ok
 }
+
+// Stacktrace gives the stack where a framework error was created, or an
+// empty string when it was not captured or the error is not a framework one.
+func Stacktrace(err error) string {
+	if e, ok := IsKnownError(err); ok {
+		return e.Stacktrace()
+	}
+
+	return ""
+}
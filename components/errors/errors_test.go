@@ -0,0 +1,31 @@
+package errors
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	merrors "github.com/somatech1/mikros/internal/components/errors"
+	"github.com/somatech1/mikros/internal/components/logger"
+)
+
+func TestStacktrace(t *testing.T) {
+	a := assert.New(t)
+
+	t.Run("should give the stack of framework errors", func(t *testing.T) {
+		factory := merrors.NewFactory(merrors.FactoryOptions{
+			ServiceName:       "example",
+			Logger:            logger.New(logger.Options{LogOnlyFatalLevel: true}),
+			CaptureStacktrace: true,
+		})
+
+		err := factory.Internal(io.ErrUnexpectedEOF).Submit(context.TODO())
+		a.Contains(Stacktrace(err), "TestStacktrace")
+	})
+
+	t.Run("should give nothing for other errors", func(t *testing.T) {
+		a.Empty(Stacktrace(io.ErrUnexpectedEOF))
+	})
+}
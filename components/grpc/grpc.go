@@ -2,10 +2,13 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
 	trackerApi "github.com/somatech1/mikros/apis/tracker"
@@ -43,6 +46,7 @@ func ClientConnection(options *ClientConnectionOptions) (*grpc.ClientConn, error
 		address,
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 		grpc.WithUnaryInterceptor(gRPCClientUnaryInterceptor(options.Context, options.Tracker, options.ServiceName, options.ClientName)),
+		grpc.WithStreamInterceptor(gRPCClientStreamInterceptor(options.Context, options.Tracker, options.ServiceName, options.ClientName)),
 	)
 	if err != nil {
 		return nil, err
@@ -70,29 +74,84 @@ func getClientConnectionAddress(options *ClientConnectionOptions) string {
 
 func gRPCClientUnaryInterceptor(svcCtx *mcontext.ServiceContext, tracker trackerApi.Tracker, from, to service.Name) grpc.UnaryClientInterceptor {
 	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
-		if tracker != nil {
-			trackId := tracker.Generate()
+		// Calls invoker with a new context.
+		if err := invoker(outgoingContext(ctx, svcCtx, tracker), method, req, reply, cc, opts...); err != nil {
+			return translateError(err, from, to)
+		}
 
-			// If we already have a tracker ID, we need to use for subsequent calls.
-			if trk, ok := tracker.Retrieve(ctx); ok {
-				trackId = trk
-			}
+		return nil
+	}
+}
 
-			// Adds the track ID on the context.
-			ctx = tracker.Add(ctx, trackId)
+func gRPCClientStreamInterceptor(svcCtx *mcontext.ServiceContext, tracker trackerApi.Tracker, from, to service.Name) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		stream, err := streamer(outgoingContext(ctx, svcCtx, tracker), desc, cc, method, opts...)
+		if err != nil {
+			return nil, translateError(err, from, to)
 		}
 
-		// Calls invoker with a new context.
-		if err := invoker(mcontext.AppendServiceContext(ctx, svcCtx), method, req, reply, cc, opts...); err != nil {
-			// Return the proper inner service error for the caller.
-			if st, ok := status.FromError(err); ok {
-				return merrors.FromGRPCStatus(st, from, to)
-			}
-
-			// Should not fall here
-			return err
+		return &clientStream{
+			ClientStream: stream,
+			from:         from,
+			to:           to,
+		}, nil
+	}
+}
+
+// outgoingContext gives the context of an outgoing call, carrying the
+// tracker ID and the ServiceContext.
+func outgoingContext(ctx context.Context, svcCtx *mcontext.ServiceContext, tracker trackerApi.Tracker) context.Context {
+	if tracker != nil {
+		trackId := tracker.Generate()
+
+		// If we already have a tracker ID, we need to use for subsequent calls.
+		if trk, ok := tracker.Retrieve(ctx); ok {
+			trackId = trk
 		}
 
-		return nil
+		// Adds the track ID on the context.
+		ctx = tracker.Add(ctx, trackId)
+	}
+
+	return mcontext.AppendServiceContext(ctx, svcCtx)
+}
+
+// translateError returns the proper inner service error for the caller.
+func translateError(err error, from, to service.Name) error {
+	if err == nil || errors.Is(err, io.EOF) {
+		return err
+	}
+
+	if st, ok := status.FromError(err); ok {
+		return merrors.FromGRPCStatus(st, from, to)
 	}
+
+	// Should not fall here
+	return err
+}
+
+// clientStream translates the errors received by a client stream, so that
+// streaming calls give the same errors as unary ones. io.EOF, which only
+// indicates the end of the stream, is kept.
+type clientStream struct {
+	grpc.ClientStream
+	from service.Name
+	to   service.Name
+}
+
+func (c *clientStream) SendMsg(m interface{}) error {
+	return translateError(c.ClientStream.SendMsg(m), c.from, c.to)
+}
+
+func (c *clientStream) RecvMsg(m interface{}) error {
+	return translateError(c.ClientStream.RecvMsg(m), c.from, c.to)
+}
+
+func (c *clientStream) CloseSend() error {
+	return translateError(c.ClientStream.CloseSend(), c.from, c.to)
+}
+
+func (c *clientStream) Header() (metadata.MD, error) {
+	md, err := c.ClientStream.Header()
+	return md, translateError(err, c.from, c.to)
 }
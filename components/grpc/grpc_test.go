@@ -0,0 +1,73 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	errorsApi "github.com/somatech1/mikros/apis/errors"
+	mcontext "github.com/somatech1/mikros/components/context"
+	"github.com/somatech1/mikros/components/service"
+	merrors "github.com/somatech1/mikros/internal/components/errors"
+)
+
+func TestClientStreamInterceptor(t *testing.T) {
+	a := assert.New(t)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	a.NoError(err)
+
+	// Every stream answers with the caller name received inside its
+	// ServiceContext, followed by a framework error.
+	server := grpc.NewServer(grpc.UnknownServiceHandler(func(_ interface{}, stream grpc.ServerStream) error {
+		caller := ""
+		if svcCtx, ok := mcontext.FromContext(stream.Context()); ok {
+			caller, _ = svcCtx.Get("caller")
+		}
+		_ = stream.SetHeader(metadata.Pairs("caller", caller))
+
+		return status.Error(codes.Unknown, `{"kind":"NotFoundError","service_name":"server","message":"not found"}`)
+	}))
+	go func() {
+		_ = server.Serve(l)
+	}()
+	defer server.Stop()
+
+	svcCtx, err := mcontext.New(&mcontext.Options{Name: service.FromString("client")})
+	a.NoError(err)
+
+	conn, err := ClientConnection(&ClientConnectionOptions{
+		ServiceName: service.FromString("client"),
+		ClientName:  service.FromString("server"),
+		Context:     svcCtx,
+		Connection: ConnectionOptions{
+			Host: "127.0.0.1",
+			Port: int32(l.Addr().(*net.TCPAddr).Port),
+		},
+	})
+	a.NoError(err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, "/example.Service/Stream")
+	a.NoError(err)
+
+	md, err := stream.Header()
+	a.NoError(err)
+	a.Equal([]string{"client"}, md.Get("caller"))
+
+	err = stream.RecvMsg(&struct{}{})
+	var serviceErr *merrors.Error
+	a.ErrorAs(err, &serviceErr)
+	a.Equal(errorsApi.KindNotFound, serviceErr.Kind)
+	a.Equal("server", serviceErr.ServiceName)
+}
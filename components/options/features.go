@@ -17,4 +17,5 @@ const (
 	TrackerFeatureName         = FeatureNamePrefix + "tracker"
 	LoggerExtractorFeatureName = FeatureNamePrefix + "logger_extractor"
 	PanicRecoveryFeatureName   = FeatureNamePrefix + "panic_recovery"
+	HttpProblemFeatureName     = FeatureNamePrefix + "http_problem"
 )
@@ -2,6 +2,7 @@ package options
 
 import (
 	"errors"
+	"io"
 
 	"github.com/go-playground/validator/v10"
 
@@ -13,7 +14,8 @@ import (
 type NewServiceOptions struct {
 	// Service must have all required service options according the types
 	// defined in the 'service.toml' file. The same type name should be
-	// used as key here.
+	// used as key here. Named instances may use 'type:name' as key to have
+	// their own options, otherwise they use the options of their type.
 	Service map[string]ServiceOptions `validate:"required"`
 
 	// RunTimeFeatures must hold everything that will only be available
@@ -24,8 +26,51 @@ type NewServiceOptions struct {
 	// GrpcClients should have every gRPC dependency that the service
 	// may have.
 	GrpcClients map[string]*GrpcClient
+
+	// Definitions, when set, is used as the service definitions instead of
+	// loading them from the 'service.toml' file. It should be created with
+	// definition.New so that it has its default values.
+	Definitions *definition.Definitions
+
+	// DefinitionsSource, when set, is used as the 'service.toml' content
+	// instead of reading it from the disk.
+	DefinitionsSource io.Reader
+
+	// CustomDefinitions, when set, must be a pointer to a struct where the
+	// service custom settings, i.e., the [service] section of the definitions,
+	// are decoded into. They are decoded, with their default values and
+	// validation rules, before the service starts.
+	CustomDefinitions interface{}
+
+	// TestMode sets how the service detects that it is running inside tests,
+	// where its deployment environment becomes 'test'. By default, it is
+	// detected when the service runs inside a test binary. Setting the
+	// MIKROS_SERVICE_DEPLOY environment variable to 'test' always enables it.
+	TestMode TestMode
+
+	// DisableConfigFlag disables the framework command line flags, such as
+	// '-config', used to set an alternative path for the 'service.toml' file,
+	// and '-config-overlay'. When disabled, the framework does not parse the
+	// command line flags.
+	DisableConfigFlag bool
 }
 
+// TestMode is the way a service detects that it is running inside tests.
+type TestMode int
+
+const (
+	// TestModeAuto enables the test mode when the service is running inside
+	// a test binary, i.e., one built by 'go test'.
+	TestModeAuto TestMode = iota
+
+	// TestModeEnabled always enables the test mode.
+	TestModeEnabled
+
+	// TestModeDisabled never enables the test mode by itself, allowing a
+	// service to be entirely executed inside tests.
+	TestModeDisabled
+)
+
 // ServiceOptions is an interface that all services options structure must
 // implement.
 type ServiceOptions interface {
@@ -46,6 +91,10 @@ func (o *NewServiceOptions) Validate() error {
 		return err
 	}
 
+	if o.Definitions != nil && o.DefinitionsSource != nil {
+		return errors.New("cannot use both Definitions and DefinitionsSource")
+	}
+
 	// Initialize default values for optional members if everything is right.
 
 	if o.GrpcClients == nil {
@@ -33,3 +33,39 @@ type Env interface {
 	// HttpPort returns the port number that HTTP services should use.
 	HttpPort() int32
 }
+
+// EnvDeployment is an optional interface that an Env can implement to give
+// details of deployment environments declared inside the [environments]
+// section of the service definitions, which are ServiceDeploy_Unknown for
+// Env.DeploymentEnv.
+type EnvDeployment interface {
+	// DeploymentEnvName gives the name of the current deployment environment.
+	DeploymentEnvName() string
+
+	// DeploymentTraits gives the traits of the current deployment environment.
+	// Features should rely on them, instead of on the environment itself, to
+	// decide how to behave.
+	DeploymentTraits() definition.DeploymentTraits
+}
+
+// DeploymentEnvName gives the name of the current deployment environment of
+// env, falling back to the name of its DeploymentEnv when env does not
+// implement EnvDeployment.
+func DeploymentEnvName(env Env) string {
+	if e, ok := env.(EnvDeployment); ok {
+		return e.DeploymentEnvName()
+	}
+
+	return env.DeploymentEnv().String()
+}
+
+// DeploymentTraits gives the traits of the current deployment environment of
+// env, falling back to the traits of its DeploymentEnv when env does not
+// implement EnvDeployment.
+func DeploymentTraits(env Env) definition.DeploymentTraits {
+	if e, ok := env.(EnvDeployment); ok {
+		return e.DeploymentTraits()
+	}
+
+	return env.DeploymentEnv().Traits()
+}
@@ -5,6 +5,7 @@ import (
 
 	errorsApi "github.com/somatech1/mikros/apis/errors"
 	loggerApi "github.com/somatech1/mikros/apis/logger"
+	secretsApi "github.com/somatech1/mikros/apis/secrets"
 	mcontext "github.com/somatech1/mikros/components/context"
 	"github.com/somatech1/mikros/components/definition"
 	"github.com/somatech1/mikros/components/service"
@@ -81,9 +82,25 @@ type FeatureSettings interface {
 	//   custom_setting_a = 42
 	//   custom_setting_b = "hello"
 	//
+	// Note that path always points to the main 'service.toml' file, so its
+	// overlays are not considered here.
 	Definitions(path string) (definition.ExternalFeatureEntry, error)
 }
 
+// FeatureSettingsDecoder is an optional behavior, similar to FeatureSettings,
+// that a feature may have to load custom settings from the service definitions,
+// independently of where they were loaded from. When a feature implements
+// both, this one is used.
+type FeatureSettingsDecoder interface {
+	// DecodeDefinitions must return the feature definitions decoded from its
+	// section, i.e., the [features.<name>] object of the service definitions,
+	// where name is the feature name without the framework prefix.
+	//
+	// The feature should use section.Decode so that its default values and
+	// validation rules are applied by the framework.
+	DecodeDefinitions(section *definition.Section) (definition.ExternalFeatureEntry, error)
+}
+
 // FeatureExternalAPI is a behavior that every external feature must have so that
 // their API can be used from services. This is specific for features that support
 // test mocking.
@@ -114,11 +131,26 @@ type FeatureTester interface {
 	DoTest(ctx context.Context, t *testing.Testing, serviceName service.Name) error
 }
 
+// FeatureHealthChecker is an optional behavior that a feature may have to take
+// part in the service health checks. It is only called while the feature is
+// enabled.
+type FeatureHealthChecker interface {
+	// Liveness must return an error if the feature is in a state that it can
+	// only recover by restarting the service.
+	Liveness(ctx context.Context) error
+
+	// Readiness must return an error if the feature is temporarily unable to
+	// handle requests, for example, while reconnecting to a database.
+	Readiness(ctx context.Context) error
+}
+
 // CanBeInitializedOptions gathers all information passed to the CanBeInitialized
 // method of a Feature interface.
 type CanBeInitializedOptions struct {
-	DeploymentEnv definition.ServiceDeploy
-	Definitions   *definition.Definitions
+	DeploymentEnv     definition.ServiceDeploy
+	DeploymentEnvName string
+	DeploymentTraits  definition.DeploymentTraits
+	Definitions       *definition.Definitions
 }
 
 // InitializeOptions gathers all information passed to the Initialize method of
@@ -132,4 +164,9 @@ type InitializeOptions struct {
 	Dependencies    map[string]Feature
 	RunTimeFeatures map[string]interface{}
 	Env             Env
+
+	// Secrets gives access to all secret providers available for the
+	// service. It can be used with env.WithSecrets to load env fields
+	// tagged as secrets.
+	Secrets secretsApi.Provider
 }
@@ -34,8 +34,10 @@ func NewFeatureSet() *FeatureSet {
 func (s *FeatureSet) InitializeAll(ctx context.Context, options *InitializeOptions) error {
 	for _, feature := range s.orderedFeatures {
 		allowOptions := &CanBeInitializedOptions{
-			DeploymentEnv: options.Env.DeploymentEnv(),
-			Definitions:   options.Definitions,
+			DeploymentEnv:     options.Env.DeploymentEnv(),
+			DeploymentEnvName: DeploymentEnvName(options.Env),
+			DeploymentTraits:  DeploymentTraits(options.Env),
+			Definitions:       options.Definitions,
 		}
 
 		createOptions := &InitializeOptions{
@@ -47,6 +49,7 @@ func (s *FeatureSet) InitializeAll(ctx context.Context, options *InitializeOptio
 			Dependencies:    s.getDependentFeatures(feature.dependencies),
 			RunTimeFeatures: options.RunTimeFeatures,
 			Env:             options.Env,
+			Secrets:         options.Secrets,
 		}
 
 		if err := s.initializeFeature(ctx, feature.feature, allowOptions, createOptions); err != nil {
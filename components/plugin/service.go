@@ -2,8 +2,10 @@ package plugin
 
 import (
 	"context"
+	"net"
 
 	errorsApi "github.com/somatech1/mikros/apis/errors"
+	healthApi "github.com/somatech1/mikros/apis/health"
 	loggerApi "github.com/somatech1/mikros/apis/logger"
 	mcontext "github.com/somatech1/mikros/components/context"
 	"github.com/somatech1/mikros/components/definition"
@@ -29,10 +31,31 @@ type Service interface {
 	// Run must put the server in execution. It can block or not the call.
 	Run(ctx context.Context, srv interface{}) error
 
-	// Stop should stop the service with a graceful shutdown.
+	// Stop should stop the service with a graceful shutdown. The received
+	// context has the deadline that the service has to finish its in-flight
+	// requests before being forced to stop. It must return once the deadline
+	// is reached, giving the context error when it was forced to stop.
 	Stop(ctx context.Context) error
 }
 
+// ServiceListener is an optional behavior that a service may have to give the
+// address where it is listening for connections.
+type ServiceListener interface {
+	// Addr must return the service listener address. It is only called
+	// after the service is initialized.
+	Addr() net.Addr
+}
+
+// ServiceInstanceCreator is an optional behavior that a service may have to
+// support more than one named instance of its type in the same service, like
+// 'types = ["http:public:8080", "http:internal:9090"]'. The first instance
+// always uses the registered service.
+type ServiceInstanceCreator interface {
+	// NewInstance must return a new, not initialized, service of the same
+	// type.
+	NewInstance() Service
+}
+
 // ServiceSettings is an optional behavior that a plugin may have to load custom
 // settings from the service 'service.toml' file.
 type ServiceSettings interface {
@@ -47,13 +70,30 @@ type ServiceSettings interface {
 	//   custom_setting_a = 42
 	//   custom_setting_b = "hello"
 	//
+	// Note that path always points to the main 'service.toml' file, so its
+	// overlays are not considered here.
 	Definitions(path string) (definition.ExternalServiceEntry, error)
 }
 
+// ServiceSettingsDecoder is an optional behavior, similar to ServiceSettings,
+// that a plugin may have to load custom settings from the service definitions,
+// independently of where they were loaded from. When a plugin implements both,
+// this one is used.
+type ServiceSettingsDecoder interface {
+	// DecodeDefinitions must return the service definitions decoded from its
+	// section, i.e., the [services.<name>] object of the service definitions,
+	// where name is the service Name().
+	//
+	// The plugin should use section.Decode so that its default values and
+	// validation rules are applied by the framework.
+	DecodeDefinitions(section *definition.Section) (definition.ExternalServiceEntry, error)
+}
+
 // ServiceOptions gathers all available options to create a service object.
 type ServiceOptions struct {
 	Port           service.ServerPort
 	Type           definition.ServiceType
+	Instance       string
 	Name           service.Name
 	Product        string
 	Logger         loggerApi.Logger
@@ -65,4 +105,15 @@ type ServiceOptions struct {
 	Features       *FeatureSet
 	ServiceHandler interface{}
 	Env            Env
+	Health         healthApi.Checker
+	BuildInfo      *service.BuildInfo
+
+	// Listener, when set, is the listener that the service must use instead
+	// of creating its own, like when it is shared with other services or
+	// inherited from the process manager.
+	Listener net.Listener
+
+	// Socket is the path of the unix socket that the service must listen
+	// on, instead of using Port, when set.
+	Socket string
 }
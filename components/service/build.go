@@ -0,0 +1,76 @@
+package service
+
+import (
+	"runtime/debug"
+	"strconv"
+)
+
+// BuildInfo gathers information about how the service binary was built.
+type BuildInfo struct {
+	// Module is the service main module path.
+	Module string `json:"module,omitempty"`
+
+	// ModuleVersion is the main module version, which is '(devel)' when the
+	// binary was not built from a module version.
+	ModuleVersion string `json:"module_version,omitempty"`
+
+	// GoVersion is the Go version used to build the binary.
+	GoVersion string `json:"go_version,omitempty"`
+
+	// Revision is the VCS revision the binary was built from.
+	Revision string `json:"revision,omitempty"`
+
+	// Time is the VCS revision time.
+	Time string `json:"time,omitempty"`
+
+	// Dirty tells if the VCS working tree had local modifications.
+	Dirty bool `json:"dirty"`
+}
+
+// ReadBuildInfo gives the build information embedded into the running
+// binary. It returns false if the binary was not built with module support.
+func ReadBuildInfo() (*BuildInfo, bool) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil, false
+	}
+
+	b := &BuildInfo{
+		Module:        info.Main.Path,
+		ModuleVersion: info.Main.Version,
+		GoVersion:     info.GoVersion,
+	}
+
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			b.Revision = setting.Value
+		case "vcs.time":
+			b.Time = setting.Value
+		case "vcs.modified":
+			b.Dirty, _ = strconv.ParseBool(setting.Value)
+		}
+	}
+
+	return b, true
+}
+
+// IsDevel returns if the binary was built without a module version, like
+// when it is built from its own module sources.
+func (b *BuildInfo) IsDevel() bool {
+	return b.ModuleVersion == "" || b.ModuleVersion == "(devel)"
+}
+
+// Attributes gives the build information as log attributes.
+func (b *BuildInfo) Attributes() map[string]string {
+	attrs := map[string]string{
+		"service.build.go_version": b.GoVersion,
+	}
+
+	if b.Revision != "" {
+		attrs["service.build.revision"] = b.Revision
+		attrs["service.build.dirty"] = strconv.FormatBool(b.Dirty)
+	}
+
+	return attrs
+}
@@ -1,12 +1,15 @@
 package mikros
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strings"
+	gotesting "testing"
 
 	"github.com/somatech1/mikros/components/definition"
 	"github.com/somatech1/mikros/components/env"
+	"github.com/somatech1/mikros/components/options"
 )
 
 const (
@@ -22,6 +25,11 @@ type Env struct {
 	DeploymentEnv     definition.ServiceDeploy `env:"MIKROS_SERVICE_DEPLOY,default_value=local"`
 	TrackerHeaderName string                   `env:"MIKROS_TRACKER_HEADER_NAME,default_value=X-Request-ID"`
 
+	// DeploymentEnvName is the name of the deployment environment. Unlike
+	// DeploymentEnv, which is ServiceDeploy_Unknown for them, it also holds
+	// the environments declared inside the [environments] section.
+	DeploymentEnvName string `env:"MIKROS_SERVICE_DEPLOY,default_value=local"`
+
 	// CI/CD settings
 	IsCICD bool `env:"MIKROS_CICD_TEST,default_value=false"`
 
@@ -33,46 +41,66 @@ type Env struct {
 	GrpcPort int32 `env:"MIKROS_GRPC_PORT,default_value=7070"`
 	HttpPort int32 `env:"MIKROS_HTTP_PORT,default_value=8080"`
 
+	// SecretsPath is the directory where the built-in secrets provider
+	// searches for secret files.
+	SecretsPath string `env:"MIKROS_SECRETS_PATH,default_value=/run/secrets"`
+
 	// definedEnvs holds all variables pointed directly into the 'service.toml'
 	// file.
 	definedEnvs map[string]string `env:",skip"`
+
+	// traits holds the characteristics of the deployment environment.
+	traits definition.DeploymentTraits `env:",skip"`
 }
 
-func newEnv(defs *definition.Definitions) (*Env, error) {
+// newEnv loads the framework environment variables and the ones declared by
+// the definitions. The environment is always given back, even when some of
+// its variables could not be loaded, so that they can be reported without
+// aborting.
+func newEnv(defs *definition.Definitions, testMode options.TestMode) (*Env, error) {
 	var envs Env
-	if err := env.Load(defs.ServiceName(), &envs); err != nil {
-		return nil, err
-	}
-
-	envs.autoAdjust()
+	err := env.Load(defs.ServiceName(), &envs)
+	envs.autoAdjust(testMode)
 
 	// Load service defined environment variables (through service.toml 'envs' key)
-	definedEnvs, err := loadDefinedEnvVars(defs)
+	definedEnvs, definedErr := loadDefinedEnvVars(defs)
+	envs.definedEnvs = definedEnvs
+
+	return &envs, errors.Join(err, definedErr)
+}
+
+// loadDeploymentTraits loads the traits of the deployment environment, which
+// must be known by the service definitions.
+func (e *Env) loadDeploymentTraits(defs *definition.Definitions) error {
+	traits, err := defs.DeploymentTraits(e.DeploymentEnvName)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	envs.definedEnvs = definedEnvs
 
-	return &envs, nil
+	e.traits = traits
+	return nil
 }
 
 // loadDefinedEnvVars loads envs defined in the 'service.toml' file as mandatory
-// values, í.e., they must be available when the service starts.
+// values, í.e., they must be available when the service starts. Every
+// variable that is not set is reported.
 func loadDefinedEnvVars(defs *definition.Definitions) (map[string]string, error) {
 	var (
 		envs = make(map[string]string)
+		errs []error
 	)
 
 	for _, e := range defs.Envs {
 		v, err := mustGetEnv(e)
 		if err != nil {
-			return nil, err
+			errs = append(errs, err)
+			continue
 		}
 
 		envs[e] = v
 	}
 
-	return envs, nil
+	return envs, errors.Join(errs...)
 }
 
 // mustGetEnv retrieves a value from an environment variable and aborts
@@ -88,22 +116,26 @@ func mustGetEnv(name string) (string, error) {
 
 // autoAdjust verifies if the local environment has any modification that
 // needs to be reflected in the structure members.
-func (e *Env) autoAdjust() {
+func (e *Env) autoAdjust(testMode options.TestMode) {
 	// Checks our real deployment environment
-	if e.isRunningTest() {
+	if isRunningTest(testMode) {
 		e.DeploymentEnv = definition.ServiceDeploy_Test
+		e.DeploymentEnvName = definition.ServiceDeploy_Test.String()
 	}
 }
 
-// isRunningTest returns if the current session is being executed in test mode.
-func (e *Env) isRunningTest() bool {
-	for _, arg := range os.Args {
-		if strings.HasSuffix(arg, ".test") || strings.Contains(arg, "-test") {
-			return true
-		}
+// isRunningTest returns if the current session is being executed in test mode,
+// according to the service option. When it must be detected, the test mode is
+// enabled inside test binaries.
+func isRunningTest(testMode options.TestMode) bool {
+	switch testMode {
+	case options.TestModeEnabled:
+		return true
+	case options.TestModeDisabled:
+		return false
 	}
 
-	return false
+	return gotesting.Testing()
 }
 
 func (e *Env) DefinedEnv(name string) (string, bool) {
@@ -126,6 +158,14 @@ func (m *MapEnv) DeploymentEnv() definition.ServiceDeploy {
 	return m.env.DeploymentEnv
 }
 
+func (m *MapEnv) DeploymentEnvName() string {
+	return m.env.DeploymentEnvName
+}
+
+func (m *MapEnv) DeploymentTraits() definition.DeploymentTraits {
+	return m.env.traits
+}
+
 func (m *MapEnv) TrackerHeaderName() string {
 	return m.env.TrackerHeaderName
 }
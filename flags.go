@@ -0,0 +1,39 @@
+package mikros
+
+import (
+	"flag"
+	"sync"
+)
+
+// commandLineFlags gathers the framework command line flags.
+type commandLineFlags struct {
+	config         string
+	configOverlay  string
+	validateConfig bool
+	printSchema    bool
+}
+
+var declareFlagsOnce sync.Once
+
+// parseFlags declares and parses the framework command line flags. They are
+// declared only once, so that more than one service can be created by the
+// same program.
+func parseFlags() *commandLineFlags {
+	declareFlagsOnce.Do(func() {
+		flag.String("config", "", "Sets the alternative path for 'service.toml' file.")
+		flag.String("config-overlay", "", "Sets a file to be merged on top of the 'service.toml' file.")
+		flag.Bool("validate-config", false, "Validates the service definitions and exits without starting the service.")
+		flag.Bool("print-schema", false, "Prints the JSON Schema of the service definitions and exits without starting the service.")
+	})
+
+	if !flag.Parsed() {
+		flag.Parse()
+	}
+
+	return &commandLineFlags{
+		config:         flag.Lookup("config").Value.String(),
+		configOverlay:  flag.Lookup("config-overlay").Value.String(),
+		validateConfig: flag.Lookup("validate-config").Value.String() == "true",
+		printSchema:    flag.Lookup("print-schema").Value.String() == "true",
+	}
+}
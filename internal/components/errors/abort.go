@@ -20,3 +20,7 @@ func NewAbortError(message string, err error) *AbortError {
 func (s *AbortError) Error() string {
 	return fmt.Sprintf("%v:%v", s.Message, s.InnerError.Error())
 }
+
+func (s *AbortError) Unwrap() error {
+	return s.InnerError
+}
@@ -31,6 +31,9 @@ type serviceErrorOptions struct {
 	Destination string
 	Logger      func(ctx context.Context, msg string, attrs ...loggerApi.Attribute)
 	Error       error
+
+	// stack holds the stack where the error was created, when captured.
+	stack []uintptr
 }
 
 func newServiceError(options *serviceErrorOptions) *ServiceError {
@@ -40,6 +43,8 @@ func newServiceError(options *serviceErrorOptions) *ServiceError {
 		Message:     options.Message,
 		Destination: options.Destination,
 		Kind:        options.Kind,
+		cause:       options.Error,
+		stack:       options.stack,
 	}
 
 	if options.Error != nil {
@@ -52,30 +57,43 @@ func newServiceError(options *serviceErrorOptions) *ServiceError {
 	}
 }
 
+// FromGRPCStatus gives back the framework error received from a gRPC call.
+// The error is read from the status details or, for peers that do not send
+// them yet, from the status message.
 func FromGRPCStatus(st *status.Status, from, to service.Name) error {
+	if e, ok := errorFromDetails(st); ok {
+		return e
+	}
+
 	var (
 		msg    = st.Message()
 		retErr Error
 	)
 
-	if err := json.Unmarshal([]byte(msg), &retErr); err != nil {
-		return newServiceError(&serviceErrorOptions{
-			Destination: to.String(),
-			Kind:        errorsApi.KindInternal,
-			ServiceName: from.String(),
-			Message:     "got an internal error",
-			Error:       errors.New(msg),
-		}).Submit(context.TODO())
+	// Older peers send their errors with the Unknown code, while newer ones,
+	// whose details may have been dropped on the way, use the code of the
+	// error kind.
+	if err := json.Unmarshal([]byte(msg), &retErr); err == nil {
+		if st.Code() == codes.Unknown || st.Code() == retErr.GRPCCode() {
+			return &retErr
+		}
 	}
 
-	// If we're dealing with a non mikros error, change it to an Internal
-	// one so services can properly handle them.
-	if st.Code() != codes.Unknown {
-		retErr.Kind = errorsApi.KindInternal
-		retErr.SubLevelError = msg
+	// If we're dealing with a non mikros error, give it the kind of its code
+	// so services can properly handle them.
+	kind := kindOf(st.Code())
+	message := "service RPC error"
+	if kind == errorsApi.KindInternal {
+		message = "got an internal error"
 	}
 
-	return &retErr
+	return newServiceError(&serviceErrorOptions{
+		Destination: to.String(),
+		Kind:        kind,
+		ServiceName: from.String(),
+		Message:     message,
+		Error:       errors.New(msg),
+	}).Submit(context.TODO())
 }
 
 func (s *ServiceError) WithCode(code errorsApi.Code) errorsApi.Error {
@@ -95,6 +113,11 @@ func (s *ServiceError) Submit(ctx context.Context) error {
 		if s.err.SubLevelError != "" {
 			logFields = append(logFields, logger.String("error.message", s.err.SubLevelError))
 		}
+		if s.err.Kind == errorsApi.KindInternal && len(s.err.stack) > 0 {
+			// Internal errors are unexpected, so where they were created
+			// helps finding their causes.
+			logFields = append(logFields, logger.String("error.stacktrace", s.err.Stacktrace()))
+		}
 
 		s.logger(ctx, s.err.Message, append(logFields, s.attributes...)...)
 	}
@@ -114,6 +137,10 @@ func withKind(kind errorsApi.Kind) loggerApi.Attribute {
 
 // Error is the framework error type that a service handler should return to
 // keep a standard error between services.
+//
+// Inside the service, the error keeps its original cause, which can be
+// checked with errors.Is and errors.As, and the stack where it was created,
+// when enabled. Only its exported fields are sent to other services.
 type Error struct {
 	Code          int32          `json:"code"`
 	ServiceName   string         `json:"service_name,omitempty"`
@@ -121,12 +148,30 @@ type Error struct {
 	Destination   string         `json:"destination,omitempty"`
 	Kind          errorsApi.Kind `json:"kind"`
 	SubLevelError string         `json:"details,omitempty"`
+
+	cause error
+	stack []uintptr
 }
 
 func (e *Error) Error() string {
 	return e.String()
 }
 
+// Unwrap gives the original cause of the error, if any.
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Stacktrace gives the stack where the error was created, or an empty
+// string when its capture is not enabled.
+func (e *Error) Stacktrace() string {
+	if len(e.stack) == 0 {
+		return ""
+	}
+
+	return formatStack(e.stack)
+}
+
 func (e *Error) String() string {
 	b, _ := json.Marshal(e)
 	return string(b)
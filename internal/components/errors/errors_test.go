@@ -0,0 +1,115 @@
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	errorsApi "github.com/somatech1/mikros/apis/errors"
+	loggerApi "github.com/somatech1/mikros/apis/logger"
+	"github.com/somatech1/mikros/internal/components/logger"
+)
+
+// recordLogger is a logger that keeps the attributes of its error messages.
+type recordLogger struct {
+	loggerApi.Logger
+	attributes map[string]interface{}
+}
+
+func (l *recordLogger) Error(_ context.Context, _ string, attrs ...loggerApi.Attribute) {
+	l.record(attrs)
+}
+
+func (l *recordLogger) Warn(_ context.Context, _ string, attrs ...loggerApi.Attribute) {
+	l.record(attrs)
+}
+
+func (l *recordLogger) record(attrs []loggerApi.Attribute) {
+	l.attributes = make(map[string]interface{})
+	for _, attr := range attrs {
+		l.attributes[attr.Key()] = attr.Value()
+	}
+}
+
+func TestServiceErrorCause(t *testing.T) {
+	a := assert.New(t)
+	newFactory := func(captureStacktrace bool) *Factory {
+		return NewFactory(FactoryOptions{
+			ServiceName:       "example",
+			Logger:            logger.New(logger.Options{LogOnlyFatalLevel: true}),
+			CaptureStacktrace: captureStacktrace,
+		})
+	}
+
+	t.Run("should keep the original cause", func(t *testing.T) {
+		cause := fmt.Errorf("could not read record: %w", io.ErrUnexpectedEOF)
+		err := newFactory(false).Internal(cause).Submit(context.TODO())
+
+		a.ErrorIs(err, io.ErrUnexpectedEOF)
+		var e *Error
+		a.ErrorAs(err, &e)
+		a.Equal(cause, errors.Unwrap(e))
+		a.Empty(e.Stacktrace())
+	})
+
+	t.Run("should keep the same JSON", func(t *testing.T) {
+		err := newFactory(true).Internal(io.ErrUnexpectedEOF).Submit(context.TODO())
+
+		var e Error
+		a.NoError(json.Unmarshal([]byte(err.Error()), &e))
+		a.Equal(Error{
+			ServiceName:   "example",
+			Message:       "got an internal error",
+			Kind:          errorsApi.KindInternal,
+			SubLevelError: io.ErrUnexpectedEOF.Error(),
+		}, e)
+	})
+
+	t.Run("should capture the stack", func(t *testing.T) {
+		err := newFactory(true).NotFound().Submit(context.TODO())
+
+		var e *Error
+		a.ErrorAs(err, &e)
+		a.Regexp(`^github.com/somatech1/mikros/internal/components/errors.TestServiceErrorCause.func\d+\n\t.*errors_test.go:\d+\n`, e.Stacktrace())
+	})
+}
+
+func TestServiceErrorStacktraceLog(t *testing.T) {
+	a := assert.New(t)
+	newFactory := func(log loggerApi.Logger, captureStacktrace bool) *Factory {
+		return NewFactory(FactoryOptions{
+			ServiceName:       "example",
+			Logger:            log,
+			CaptureStacktrace: captureStacktrace,
+		})
+	}
+
+	t.Run("should log the stack of internal errors", func(t *testing.T) {
+		log := &recordLogger{}
+		_ = newFactory(log, true).Internal(io.ErrUnexpectedEOF).Submit(context.TODO())
+
+		a.Contains(log.attributes, "error.stacktrace")
+		a.Contains(log.attributes["error.stacktrace"], "TestServiceErrorStacktraceLog")
+	})
+
+	t.Run("should not log the stack of other errors", func(t *testing.T) {
+		log := &recordLogger{}
+		_ = newFactory(log, true).NotFound().Submit(context.TODO())
+
+		a.Contains(log.attributes, "error.kind")
+		a.NotContains(log.attributes, "error.stacktrace")
+	})
+
+	t.Run("should not log the stack when it is not captured", func(t *testing.T) {
+		log := &recordLogger{}
+		_ = newFactory(log, false).Internal(io.ErrUnexpectedEOF).Submit(context.TODO())
+
+		a.Contains(log.attributes, "error.kind")
+		a.NotContains(log.attributes, "error.stacktrace")
+	})
+}
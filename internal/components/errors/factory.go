@@ -8,27 +8,44 @@ import (
 )
 
 type Factory struct {
-	serviceName string
-	logger      loggerApi.Logger
+	serviceName       string
+	captureStacktrace bool
+	logger            loggerApi.Logger
 }
 
 type FactoryOptions struct {
 	ServiceName string
 	Logger      loggerApi.Logger
+
+	// CaptureStacktrace enables capturing, for every error, the stack where
+	// it was created.
+	CaptureStacktrace bool
 }
 
 // NewFactory creates a new Factory object.
 func NewFactory(options FactoryOptions) *Factory {
 	return &Factory{
-		serviceName: options.ServiceName,
-		logger:      options.Logger,
+		serviceName:       options.ServiceName,
+		captureStacktrace: options.CaptureStacktrace,
+		logger:            options.Logger,
 	}
 }
 
+// newServiceError creates a new error, capturing the stack of the Factory
+// method caller when enabled.
+func (f *Factory) newServiceError(options *serviceErrorOptions) *ServiceError {
+	if f.captureStacktrace {
+		// skip [callers, newServiceError, Factory method]
+		options.stack = callers(3)
+	}
+
+	return newServiceError(options)
+}
+
 // RPC sets that the current error is related to an RPC call with another gRPC
 // service (destination).
 func (f *Factory) RPC(err error, destination string) errorsApi.Error {
-	return newServiceError(&serviceErrorOptions{
+	return f.newServiceError(&serviceErrorOptions{
 		Kind:        errorsApi.KindRPC,
 		ServiceName: f.serviceName,
 		Message:     "service RPC error",
@@ -41,7 +58,7 @@ func (f *Factory) RPC(err error, destination string) errorsApi.Error {
 // InvalidArgument sets that the current error is related to an argument that
 // didn't follow validation rules.
 func (f *Factory) InvalidArgument(err error) errorsApi.Error {
-	return newServiceError(&serviceErrorOptions{
+	return f.newServiceError(&serviceErrorOptions{
 		Kind:        errorsApi.KindValidation,
 		ServiceName: f.serviceName,
 		Message:     "request validation failed",
@@ -53,7 +70,7 @@ func (f *Factory) InvalidArgument(err error) errorsApi.Error {
 // FailedPrecondition sets that the current error is related to an internal
 // condition which wasn't satisfied.
 func (f *Factory) FailedPrecondition(message string) errorsApi.Error {
-	return newServiceError(&serviceErrorOptions{
+	return f.newServiceError(&serviceErrorOptions{
 		Kind:        errorsApi.KindPrecondition,
 		ServiceName: f.serviceName,
 		Message:     message,
@@ -64,7 +81,7 @@ func (f *Factory) FailedPrecondition(message string) errorsApi.Error {
 // NotFound sets that the current error is related to some data not being found,
 // probably in the database.
 func (f *Factory) NotFound() errorsApi.Error {
-	return newServiceError(&serviceErrorOptions{
+	return f.newServiceError(&serviceErrorOptions{
 		Kind:        errorsApi.KindNotFound,
 		ServiceName: f.serviceName,
 		Message:     "not found",
@@ -75,7 +92,7 @@ func (f *Factory) NotFound() errorsApi.Error {
 // Internal sets that the current error is related to an internal service
 // error.
 func (f *Factory) Internal(err error) errorsApi.Error {
-	return newServiceError(&serviceErrorOptions{
+	return f.newServiceError(&serviceErrorOptions{
 		Kind:        errorsApi.KindInternal,
 		ServiceName: f.serviceName,
 		Message:     "got an internal error",
@@ -87,7 +104,7 @@ func (f *Factory) Internal(err error) errorsApi.Error {
 // PermissionDenied sets that the current error is related to a client trying
 // to access a resource without having permission to do so.
 func (f *Factory) PermissionDenied() errorsApi.Error {
-	return newServiceError(&serviceErrorOptions{
+	return f.newServiceError(&serviceErrorOptions{
 		Kind:        errorsApi.KindPermission,
 		ServiceName: f.serviceName,
 		Message:     fmt.Sprintf("no permission to access %s", f.serviceName),
@@ -95,10 +112,68 @@ func (f *Factory) PermissionDenied() errorsApi.Error {
 	})
 }
 
+// Conflict sets that the current error is related to a request that conflicts
+// with the current state of a resource.
+func (f *Factory) Conflict(message string) errorsApi.Error {
+	return f.newServiceError(&serviceErrorOptions{
+		Kind:        errorsApi.KindConflict,
+		ServiceName: f.serviceName,
+		Message:     message,
+		Logger:      f.logger.Warn,
+	})
+}
+
+// Unauthenticated sets that the current error is related to a client that
+// could not be authenticated.
+func (f *Factory) Unauthenticated(err error) errorsApi.Error {
+	return f.newServiceError(&serviceErrorOptions{
+		Kind:        errorsApi.KindUnauthenticated,
+		ServiceName: f.serviceName,
+		Message:     "request authentication failed",
+		Logger:      f.logger.Info,
+		Error:       err,
+	})
+}
+
+// RateLimited sets that the current error is related to a client making more
+// requests than it is allowed to.
+func (f *Factory) RateLimited() errorsApi.Error {
+	return f.newServiceError(&serviceErrorOptions{
+		Kind:        errorsApi.KindRateLimit,
+		ServiceName: f.serviceName,
+		Message:     "too many requests",
+		Logger:      f.logger.Warn,
+	})
+}
+
+// Timeout sets that the current error is related to an operation that did
+// not finish in time.
+func (f *Factory) Timeout(err error) errorsApi.Error {
+	return f.newServiceError(&serviceErrorOptions{
+		Kind:        errorsApi.KindTimeout,
+		ServiceName: f.serviceName,
+		Message:     "operation timed out",
+		Logger:      f.logger.Error,
+		Error:       err,
+	})
+}
+
+// Unavailable sets that the current error is related to a resource or a
+// dependency that is temporarily not available.
+func (f *Factory) Unavailable(err error) errorsApi.Error {
+	return f.newServiceError(&serviceErrorOptions{
+		Kind:        errorsApi.KindUnavailable,
+		ServiceName: f.serviceName,
+		Message:     "service unavailable",
+		Logger:      f.logger.Warn,
+		Error:       err,
+	})
+}
+
 // Custom lets a service set a custom error kind for its errors. Internally, it
 // will be treated as an Internal error.
 func (f *Factory) Custom(msg string) errorsApi.Error {
-	return newServiceError(&serviceErrorOptions{
+	return f.newServiceError(&serviceErrorOptions{
 		Kind:        errorsApi.KindCustom,
 		ServiceName: f.serviceName,
 		Message:     msg,
@@ -0,0 +1,58 @@
+package errors
+
+import (
+	"encoding/json"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// errorInfoDomain identifies, inside a gRPC status details, the error
+	// information added by the framework.
+	errorInfoDomain = "mikros"
+
+	// errorInfoKey is the error information metadata key that holds the
+	// framework error.
+	errorInfoKey = "error"
+)
+
+// GRPCStatus gives the gRPC status used when the error is returned by a gRPC
+// handler. Its code is mapped from the error kind, and the error travels as
+// an errdetails.ErrorInfo inside the status details. The status message
+// keeps the error JSON, so that peers that do not read the details are still
+// able to read it.
+func (e *Error) GRPCStatus() *status.Status {
+	st := status.New(e.GRPCCode(), e.String())
+
+	withDetails, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   string(e.Kind),
+		Domain:   errorInfoDomain,
+		Metadata: map[string]string{errorInfoKey: e.String()},
+	})
+	if err != nil {
+		return st
+	}
+
+	return withDetails
+}
+
+// errorFromDetails gives the framework error carried by the details of a
+// gRPC status, if any.
+func errorFromDetails(st *status.Status) (*Error, bool) {
+	for _, d := range st.Details() {
+		info, ok := d.(*errdetails.ErrorInfo)
+		if !ok || info.GetDomain() != errorInfoDomain {
+			continue
+		}
+
+		var e Error
+		if err := json.Unmarshal([]byte(info.GetMetadata()[errorInfoKey]), &e); err != nil {
+			continue
+		}
+
+		return &e, true
+	}
+
+	return nil, false
+}
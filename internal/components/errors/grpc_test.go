@@ -0,0 +1,76 @@
+package errors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	errorsApi "github.com/somatech1/mikros/apis/errors"
+	"github.com/somatech1/mikros/components/service"
+)
+
+func TestGRPCStatus(t *testing.T) {
+	a := assert.New(t)
+	var (
+		from = service.FromString("client")
+		to   = service.FromString("server")
+	)
+
+	t.Run("should map kinds to gRPC codes", func(t *testing.T) {
+		for kind, code := range map[errorsApi.Kind]codes.Code{
+			errorsApi.KindValidation:      codes.InvalidArgument,
+			errorsApi.KindNotFound:        codes.NotFound,
+			errorsApi.KindPrecondition:    codes.FailedPrecondition,
+			errorsApi.KindPermission:      codes.PermissionDenied,
+			errorsApi.KindInternal:        codes.Internal,
+			errorsApi.KindCustom:          codes.Internal,
+			errorsApi.KindRPC:             codes.Internal,
+			errorsApi.KindConflict:        codes.AlreadyExists,
+			errorsApi.KindUnauthenticated: codes.Unauthenticated,
+			errorsApi.KindRateLimit:       codes.ResourceExhausted,
+			errorsApi.KindTimeout:         codes.DeadlineExceeded,
+			errorsApi.KindUnavailable:     codes.Unavailable,
+		} {
+			err := &Error{Kind: kind, ServiceName: "server", Message: "failed", Code: 42}
+
+			st, ok := status.FromError(err)
+			a.True(ok)
+			a.Equal(code, st.Code(), kind)
+
+			received := FromGRPCStatus(st, from, to)
+			a.Equal(err, received)
+		}
+	})
+
+	t.Run("should read errors from older peers", func(t *testing.T) {
+		st := status.New(codes.Unknown, `{"code":0,"kind":"NotFoundError","service_name":"server"}`)
+
+		received := FromGRPCStatus(st, from, to)
+		a.Equal(&Error{Kind: errorsApi.KindNotFound, ServiceName: "server"}, received)
+	})
+
+	t.Run("should read errors without details", func(t *testing.T) {
+		st := status.New(codes.NotFound, `{"code":0,"kind":"NotFoundError","service_name":"server"}`)
+
+		received := FromGRPCStatus(st, from, to)
+		a.Equal(&Error{Kind: errorsApi.KindNotFound, ServiceName: "server"}, received)
+	})
+
+	t.Run("should give non framework errors the kind of their code", func(t *testing.T) {
+		for code, kind := range map[codes.Code]errorsApi.Kind{
+			codes.NotFound:    errorsApi.KindNotFound,
+			codes.Unavailable: errorsApi.KindUnavailable,
+			codes.Internal:    errorsApi.KindInternal,
+			codes.DataLoss:    errorsApi.KindInternal,
+		} {
+			received := FromGRPCStatus(status.New(code, "connection refused"), from, to)
+
+			var e *Error
+			a.ErrorAs(received, &e)
+			a.Equal(kind, e.Kind, code)
+			a.Equal("connection refused", e.SubLevelError)
+		}
+	})
+}
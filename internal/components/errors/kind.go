@@ -0,0 +1,66 @@
+package errors
+
+import (
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+
+	errorsApi "github.com/somatech1/mikros/apis/errors"
+)
+
+// kindStatus is how an error kind is represented by the supported protocols.
+type kindStatus struct {
+	grpc codes.Code
+	http int
+}
+
+// kindStatuses maps framework error kinds to their gRPC codes and HTTP
+// status codes. Kinds not mapped are handled as internal errors.
+var kindStatuses = map[errorsApi.Kind]kindStatus{
+	errorsApi.KindValidation:      {grpc: codes.InvalidArgument, http: http.StatusBadRequest},
+	errorsApi.KindNotFound:        {grpc: codes.NotFound, http: http.StatusNotFound},
+	errorsApi.KindPrecondition:    {grpc: codes.FailedPrecondition, http: http.StatusBadRequest},
+	errorsApi.KindPermission:      {grpc: codes.PermissionDenied, http: http.StatusForbidden},
+	errorsApi.KindInternal:        {grpc: codes.Internal, http: http.StatusInternalServerError},
+	errorsApi.KindCustom:          {grpc: codes.Internal, http: http.StatusInternalServerError},
+	errorsApi.KindRPC:             {grpc: codes.Internal, http: http.StatusBadGateway},
+	errorsApi.KindConflict:        {grpc: codes.AlreadyExists, http: http.StatusConflict},
+	errorsApi.KindUnauthenticated: {grpc: codes.Unauthenticated, http: http.StatusUnauthorized},
+	errorsApi.KindRateLimit:       {grpc: codes.ResourceExhausted, http: http.StatusTooManyRequests},
+	errorsApi.KindTimeout:         {grpc: codes.DeadlineExceeded, http: http.StatusGatewayTimeout},
+	errorsApi.KindUnavailable:     {grpc: codes.Unavailable, http: http.StatusServiceUnavailable},
+}
+
+func statusOf(kind errorsApi.Kind) kindStatus {
+	if s, ok := kindStatuses[kind]; ok {
+		return s
+	}
+
+	return kindStatuses[errorsApi.KindInternal]
+}
+
+// kindOf gives the error kind of a gRPC code, doing the inverse of
+// kindStatuses. Codes without a kind of their own give internal errors.
+func kindOf(code codes.Code) errorsApi.Kind {
+	if code == codes.Internal {
+		return errorsApi.KindInternal
+	}
+
+	for kind, s := range kindStatuses {
+		if s.grpc == code {
+			return kind
+		}
+	}
+
+	return errorsApi.KindInternal
+}
+
+// GRPCCode gives the gRPC code of the error kind.
+func (e *Error) GRPCCode() codes.Code {
+	return statusOf(e.Kind).grpc
+}
+
+// HTTPStatus gives the HTTP status code of the error kind.
+func (e *Error) HTTPStatus() int {
+	return statusOf(e.Kind).http
+}
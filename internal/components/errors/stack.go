@@ -0,0 +1,47 @@
+package errors
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxStackDepth is the maximum number of frames captured for an error.
+	maxStackDepth = 32
+)
+
+// callers captures the stack of the goroutine, skipping the given number of
+// frames, including callers itself.
+func callers(skip int) []uintptr {
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(skip+1, pcs)
+	return pcs[:n]
+}
+
+// formatStack formats a captured stack, one function and its location per
+// frame.
+func formatStack(pcs []uintptr) string {
+	var (
+		s      strings.Builder
+		frames = runtime.CallersFrames(pcs)
+	)
+
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" {
+			s.WriteString(frame.Function)
+			s.WriteString("\n\t")
+			s.WriteString(frame.File)
+			s.WriteString(":")
+			s.WriteString(strconv.Itoa(frame.Line))
+			s.WriteString("\n")
+		}
+
+		if !more {
+			break
+		}
+	}
+
+	return s.String()
+}
@@ -0,0 +1,95 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	healthApi "github.com/somatech1/mikros/apis/health"
+	"github.com/somatech1/mikros/components/plugin"
+)
+
+// Health is the service health subsystem. It holds the service lifecycle
+// state and combines it with features health checks to provide liveness
+// and readiness information.
+type Health struct {
+	mu        sync.RWMutex
+	state     healthApi.State
+	features  *plugin.FeatureSet
+	listeners []func(state healthApi.State)
+}
+
+// New creates a new Health object starting at the starting state.
+func New(features *plugin.FeatureSet) *Health {
+	return &Health{
+		state:    healthApi.StateStarting,
+		features: features,
+	}
+}
+
+// SetState changes the current service lifecycle state, notifying everyone
+// interested about it.
+func (h *Health) SetState(state healthApi.State) {
+	h.mu.Lock()
+	h.state = state
+	listeners := append([]func(state healthApi.State){}, h.listeners...)
+	h.mu.Unlock()
+
+	for _, l := range listeners {
+		l(state)
+	}
+}
+
+// State gives the current service lifecycle state.
+func (h *Health) State() healthApi.State {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return h.state
+}
+
+// OnStateChange registers a function that is called every time the service
+// lifecycle state changes.
+func (h *Health) OnStateChange(f func(state healthApi.State)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.listeners = append(h.listeners, f)
+}
+
+// Liveness returns an error if any enabled feature reports that it cannot
+// recover without restarting the service.
+func (h *Health) Liveness(ctx context.Context) error {
+	return h.checkFeatures(func(c plugin.FeatureHealthChecker) error {
+		return c.Liveness(ctx)
+	})
+}
+
+// Readiness returns an error if the service is not ready yet, if it is
+// draining, or if any enabled feature reports that it is not ready.
+func (h *Health) Readiness(ctx context.Context) error {
+	if state := h.State(); state != healthApi.StateReady {
+		return fmt.Errorf("service is %s", state)
+	}
+
+	return h.checkFeatures(func(c plugin.FeatureHealthChecker) error {
+		return c.Readiness(ctx)
+	})
+}
+
+func (h *Health) checkFeatures(check func(c plugin.FeatureHealthChecker) error) error {
+	iter := h.features.Iterator()
+	for f, next := iter.Next(); next; f, next = iter.Next() {
+		if !f.IsEnabled() {
+			continue
+		}
+
+		if c, ok := f.(plugin.FeatureHealthChecker); ok {
+			if err := check(c); err != nil {
+				return fmt.Errorf("feature '%s' health check failed: %w", f.Name(), err)
+			}
+		}
+	}
+
+	return nil
+}
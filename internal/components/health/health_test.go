@@ -0,0 +1,93 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	healthApi "github.com/somatech1/mikros/apis/health"
+	loggerApi "github.com/somatech1/mikros/apis/logger"
+	"github.com/somatech1/mikros/components/plugin"
+)
+
+type checkerFeature struct {
+	plugin.Entry
+	enabled   bool
+	liveness  error
+	readiness error
+}
+
+func (c *checkerFeature) CanBeInitialized(_ *plugin.CanBeInitializedOptions) bool {
+	return c.enabled
+}
+
+func (c *checkerFeature) Initialize(_ context.Context, _ *plugin.InitializeOptions) error {
+	return nil
+}
+
+func (c *checkerFeature) Fields() []loggerApi.Attribute {
+	return nil
+}
+
+func (c *checkerFeature) Liveness(_ context.Context) error {
+	return c.liveness
+}
+
+func (c *checkerFeature) Readiness(_ context.Context) error {
+	return c.readiness
+}
+
+func newFeatureSet(features ...*checkerFeature) *plugin.FeatureSet {
+	set := plugin.NewFeatureSet()
+	for i, f := range features {
+		set.Register(string(rune('a'+i)), f)
+		f.UpdateInfo(plugin.UpdateInfoEntry{Enabled: f.enabled})
+	}
+
+	return set
+}
+
+func TestReadiness(t *testing.T) {
+	a := assert.New(t)
+	ctx := context.Background()
+
+	t.Run("should not be ready before the ready state", func(t *testing.T) {
+		h := New(newFeatureSet())
+		a.Error(h.Readiness(ctx))
+		a.NoError(h.Liveness(ctx))
+
+		h.SetState(healthApi.StateReady)
+		a.NoError(h.Readiness(ctx))
+
+		h.SetState(healthApi.StateDraining)
+		a.Error(h.Readiness(ctx))
+		a.NoError(h.Liveness(ctx))
+	})
+
+	t.Run("should combine enabled features checks", func(t *testing.T) {
+		h := New(newFeatureSet(
+			&checkerFeature{enabled: true},
+			&checkerFeature{enabled: false, readiness: errors.New("disabled")},
+			&checkerFeature{enabled: true, readiness: errors.New("reconnecting")},
+		))
+
+		h.SetState(healthApi.StateReady)
+		a.ErrorContains(h.Readiness(ctx), "reconnecting")
+		a.NoError(h.Liveness(ctx))
+	})
+
+	t.Run("should notify state changes", func(t *testing.T) {
+		var states []healthApi.State
+
+		h := New(newFeatureSet())
+		h.OnStateChange(func(state healthApi.State) {
+			states = append(states, state)
+		})
+
+		h.SetState(healthApi.StateReady)
+		h.SetState(healthApi.StateDraining)
+		a.Equal([]healthApi.State{healthApi.StateReady, healthApi.StateDraining}, states)
+	})
+}
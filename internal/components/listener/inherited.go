@@ -0,0 +1,80 @@
+package listener
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// listenFdsStart is the first file descriptor passed by the process
+	// manager, right after stdin, stdout and stderr.
+	listenFdsStart = 3
+)
+
+// Inherited gives the listeners passed to the process through the
+// LISTEN_FDS and LISTEN_FDNAMES environment variables, using systemd socket
+// activation protocol, by their names. Listeners without name receive the
+// name of their file descriptor, like 'fd3'.
+//
+// These variables are removed from the environment, so that they are not
+// inherited by child processes.
+func Inherited() (map[string]net.Listener, error) {
+	return inheritedFrom(listenFdsStart)
+}
+
+// inheritedFrom gives the inherited listeners, whose file descriptors start
+// at start.
+func inheritedFrom(start int) (map[string]net.Listener, error) {
+	defer func() {
+		_ = os.Unsetenv("LISTEN_PID")
+		_ = os.Unsetenv("LISTEN_FDS")
+		_ = os.Unsetenv("LISTEN_FDNAMES")
+	}()
+
+	fds := os.Getenv("LISTEN_FDS")
+	if fds == "" {
+		return nil, nil
+	}
+
+	// Variables set for another process must be ignored.
+	if pid := os.Getenv("LISTEN_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return nil, nil
+	}
+
+	count, err := strconv.Atoi(fds)
+	if err != nil || count < 0 {
+		return nil, fmt.Errorf("invalid LISTEN_FDS value '%s'", fds)
+	}
+
+	var names []string
+	if v := os.Getenv("LISTEN_FDNAMES"); v != "" {
+		names = strings.Split(v, ":")
+	}
+
+	listeners := make(map[string]net.Listener, count)
+	for i := 0; i < count; i++ {
+		fd := start + i
+		name := fmt.Sprintf("fd%d", fd)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+
+		f := os.NewFile(uintptr(fd), name)
+		listener, err := net.FileListener(f)
+		_ = f.Close()
+		if err != nil {
+			for _, l := range listeners {
+				_ = l.Close()
+			}
+
+			return nil, fmt.Errorf("could not use inherited listener '%s': %w", name, err)
+		}
+
+		listeners[name] = listener
+	}
+
+	return listeners, nil
+}
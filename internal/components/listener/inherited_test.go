@@ -0,0 +1,105 @@
+//go:build unix
+
+package listener
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+)
+
+// testFdsStart is where the test listeners file descriptors are placed, far
+// from the ones used by the test process.
+const testFdsStart = 200
+
+// inheritListeners creates count listeners and places their file descriptors
+// where a process manager would, giving their addresses.
+func inheritListeners(t *testing.T, count int) []net.Addr {
+	var addrs []net.Addr
+	for i := 0; i < count; i++ {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+
+		f, err := l.(*net.TCPListener).File()
+		assert.NoError(t, err)
+		assert.NoError(t, unix.Dup2(int(f.Fd()), testFdsStart+i))
+
+		_ = f.Close()
+		_ = l.Close()
+		addrs = append(addrs, l.Addr())
+	}
+
+	return addrs
+}
+
+func TestInherited(t *testing.T) {
+	a := assert.New(t)
+
+	t.Run("should map listeners to their names", func(t *testing.T) {
+		addrs := inheritListeners(t, 3)
+		t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+		t.Setenv("LISTEN_FDS", "3")
+		t.Setenv("LISTEN_FDNAMES", "grpc:http.public")
+
+		listeners, err := inheritedFrom(testFdsStart)
+		a.NoError(err)
+		a.Len(listeners, 3)
+		a.Equal(addrs[0].String(), listeners["grpc"].Addr().String())
+		a.Equal(addrs[1].String(), listeners["http.public"].Addr().String())
+		a.Equal(addrs[2].String(), listeners["fd202"].Addr().String())
+
+		for _, l := range listeners {
+			a.NoError(l.Close())
+		}
+
+		// Variables are not inherited by child processes.
+		for _, name := range []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES"} {
+			_, ok := os.LookupEnv(name)
+			a.False(ok, name)
+		}
+	})
+
+	t.Run("should ignore variables of other processes", func(t *testing.T) {
+		t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+		t.Setenv("LISTEN_FDS", "1")
+
+		listeners, err := inheritedFrom(testFdsStart)
+		a.NoError(err)
+		a.Empty(listeners)
+	})
+
+	t.Run("should accept no listeners", func(t *testing.T) {
+		t.Setenv("LISTEN_FDS", "0")
+
+		listeners, err := inheritedFrom(testFdsStart)
+		a.NoError(err)
+		a.Empty(listeners)
+	})
+
+	t.Run("should fail with invalid values", func(t *testing.T) {
+		for _, fds := range []string{"-1", "one"} {
+			t.Setenv("LISTEN_FDS", fds)
+
+			_, err := inheritedFrom(testFdsStart)
+			a.ErrorContains(err, "invalid LISTEN_FDS value")
+		}
+	})
+
+	t.Run("should fail with file descriptors that are not listeners", func(t *testing.T) {
+		r, w, err := os.Pipe()
+		a.NoError(err)
+		defer w.Close()
+		a.NoError(unix.Dup2(int(r.Fd()), testFdsStart))
+		_ = r.Close()
+
+		t.Setenv("LISTEN_FDS", "1")
+		t.Setenv("LISTEN_FDNAMES", "grpc")
+
+		_, err = inheritedFrom(testFdsStart)
+		a.ErrorContains(err, "could not use inherited listener 'grpc'")
+	})
+}
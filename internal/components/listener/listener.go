@@ -0,0 +1,59 @@
+package listener
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/somatech1/mikros/components/plugin"
+	"github.com/somatech1/mikros/components/service"
+)
+
+// Listen gives the listener that a service must use: the one received in its
+// options or, otherwise, a new one using its socket or port.
+func Listen(opt *plugin.ServiceOptions) (net.Listener, error) {
+	if opt.Listener != nil {
+		return opt.Listener, nil
+	}
+
+	return New(opt.Port, opt.Socket)
+}
+
+// New creates a listener using a unix socket, when its path is set, or a TCP
+// port otherwise.
+func New(port service.ServerPort, socket string) (net.Listener, error) {
+	if socket != "" {
+		return listenUnix(socket)
+	}
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return nil, fmt.Errorf("could not listen to service port: %w", err)
+	}
+
+	return listener, nil
+}
+
+func listenUnix(path string) (net.Listener, error) {
+	// A socket file left by a process that did not finish properly would
+	// prevent the listener from being created, so it is removed when nobody
+	// is listening on it.
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.Dial("unix", path); err == nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("unix socket '%s' is already in use", path)
+		}
+
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("could not remove unix socket '%s': %w", path, err)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("could not listen to service unix socket: %w", err)
+	}
+
+	return listener, nil
+}
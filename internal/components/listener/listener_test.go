@@ -0,0 +1,45 @@
+package listener
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	a := assert.New(t)
+
+	t.Run("should listen on a free port", func(t *testing.T) {
+		l, err := New(0, "")
+		a.NoError(err)
+		defer l.Close()
+		a.NotZero(l.Addr().(*net.TCPAddr).Port)
+	})
+
+	t.Run("should listen on a unix socket", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "service.sock")
+
+		l, err := New(0, path)
+		a.NoError(err)
+		a.Equal("unix", l.Addr().Network())
+
+		_, err = New(0, path)
+		a.ErrorContains(err, "is already in use")
+		a.NoError(l.Close())
+	})
+
+	t.Run("should replace stale unix sockets", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "service.sock")
+
+		stale, err := net.Listen("unix", path)
+		a.NoError(err)
+		stale.(*net.UnixListener).SetUnlinkOnClose(false)
+		a.NoError(stale.Close())
+
+		l, err := New(0, path)
+		a.NoError(err)
+		a.NoError(l.Close())
+	})
+}
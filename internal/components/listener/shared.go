@@ -0,0 +1,239 @@
+package listener
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+)
+
+const (
+	// sniffTimeout is the maximum time that a new connection has to send
+	// enough data to have its protocol detected.
+	sniffTimeout = 10 * time.Second
+)
+
+// Shared is a listener shared between a gRPC and an HTTP server. Every
+// accepted connection is routed, by sniffing its protocol, to one of them:
+// HTTP/2 connections with 'content-type: application/grpc' requests go to the
+// gRPC listener, and all other ones go to the HTTP listener.
+type Shared struct {
+	root   net.Listener
+	grpc   *routedListener
+	http   *routedListener
+	mu     sync.Mutex
+	opened int
+}
+
+// Share starts routing connections accepted by root. The root listener is
+// closed when both gRPC and HTTP listeners are closed.
+func Share(root net.Listener) *Shared {
+	s := &Shared{
+		root:   root,
+		opened: 2,
+	}
+
+	s.grpc = newRoutedListener(root.Addr(), s.release)
+	s.http = newRoutedListener(root.Addr(), s.release)
+
+	go s.serve()
+	return s
+}
+
+// GRPC gives the listener that receives gRPC connections.
+func (s *Shared) GRPC() net.Listener {
+	return s.grpc
+}
+
+// HTTP gives the listener that receives all non gRPC connections.
+func (s *Shared) HTTP() net.Listener {
+	return s.http
+}
+
+func (s *Shared) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.opened--
+	if s.opened == 0 {
+		_ = s.root.Close()
+	}
+}
+
+func (s *Shared) serve() {
+	for {
+		conn, err := s.root.Accept()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				continue
+			}
+
+			s.grpc.stop(err)
+			s.http.stop(err)
+			return
+		}
+
+		go s.route(conn)
+	}
+}
+
+func (s *Shared) route(conn net.Conn) {
+	c := &sniffedConn{Conn: conn}
+
+	_ = conn.SetReadDeadline(time.Now().Add(sniffTimeout))
+	isGRPC := isGRPCConn(io.TeeReader(conn, &c.buf), conn)
+	_ = conn.SetReadDeadline(time.Time{})
+
+	if isGRPC {
+		s.grpc.deliver(c)
+		return
+	}
+
+	s.http.deliver(c)
+}
+
+// isGRPCConn checks if a connection starts with the HTTP/2 client preface
+// followed by a request with a gRPC content type. Since gRPC clients wait for
+// the server settings before sending their requests, an empty SETTINGS frame
+// is written to w when the client settings are received.
+func isGRPCConn(r io.Reader, w io.Writer) bool {
+	// The preface is checked while it is read, so that short HTTP/1 requests
+	// do not have to wait for more data.
+	var (
+		preface []byte
+		buf     = make([]byte, len(http2.ClientPreface))
+	)
+
+	for len(preface) < len(http2.ClientPreface) {
+		n, err := r.Read(buf[:len(http2.ClientPreface)-len(preface)])
+		preface = append(preface, buf[:n]...)
+		if !strings.HasPrefix(http2.ClientPreface, string(preface)) || err != nil {
+			return false
+		}
+	}
+
+	var (
+		isGRPC       bool
+		settingsSent bool
+		framer       = http2.NewFramer(w, r)
+		decoder      = hpack.NewDecoder(4096, func(f hpack.HeaderField) {
+			if f.Name == "content-type" && strings.HasPrefix(f.Value, "application/grpc") {
+				isGRPC = true
+			}
+		})
+	)
+
+	for {
+		frame, err := framer.ReadFrame()
+		if err != nil {
+			return false
+		}
+
+		switch f := frame.(type) {
+		case *http2.SettingsFrame:
+			if !f.IsAck() && !settingsSent {
+				if err := framer.WriteSettings(); err != nil {
+					return false
+				}
+				settingsSent = true
+			}
+
+		case *http2.HeadersFrame:
+			if _, err := decoder.Write(f.HeaderBlockFragment()); err != nil {
+				return false
+			}
+			if f.HeadersEnded() {
+				return isGRPC
+			}
+
+		case *http2.ContinuationFrame:
+			if _, err := decoder.Write(f.HeaderBlockFragment()); err != nil {
+				return false
+			}
+			if f.HeadersEnded() {
+				return isGRPC
+			}
+		}
+	}
+}
+
+// sniffedConn is a connection that gives back, when read, the data already
+// read while its protocol was being detected.
+type sniffedConn struct {
+	net.Conn
+	buf bytes.Buffer
+}
+
+func (c *sniffedConn) Read(b []byte) (int, error) {
+	if c.buf.Len() > 0 {
+		return c.buf.Read(b)
+	}
+
+	return c.Conn.Read(b)
+}
+
+// routedListener is a listener that only accepts the connections routed to
+// it.
+type routedListener struct {
+	addr    net.Addr
+	conns   chan net.Conn
+	done    chan struct{}
+	once    sync.Once
+	err     error
+	onClose func()
+}
+
+func newRoutedListener(addr net.Addr, onClose func()) *routedListener {
+	return &routedListener{
+		addr:    addr,
+		conns:   make(chan net.Conn),
+		done:    make(chan struct{}),
+		onClose: onClose,
+	}
+}
+
+func (l *routedListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+
+	case <-l.done:
+		if l.err != nil {
+			return nil, l.err
+		}
+
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *routedListener) Close() error {
+	l.stop(nil)
+	return nil
+}
+
+func (l *routedListener) Addr() net.Addr {
+	return l.addr
+}
+
+func (l *routedListener) deliver(conn net.Conn) {
+	select {
+	case l.conns <- conn:
+	case <-l.done:
+		_ = conn.Close()
+	}
+}
+
+// stop closes the listener. A non nil err is given by Accept, when the root
+// listener could not accept connections anymore.
+func (l *routedListener) stop(err error) {
+	l.once.Do(func() {
+		l.err = err
+		close(l.done)
+		l.onClose()
+	})
+}
@@ -0,0 +1,73 @@
+package listener
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestShared(t *testing.T) {
+	a := assert.New(t)
+
+	root, err := net.Listen("tcp", "127.0.0.1:0")
+	a.NoError(err)
+
+	shared := Share(root)
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go func() {
+		_ = server.Serve(shared.GRPC())
+	}()
+
+	t.Run("should route HTTP connections", func(t *testing.T) {
+		conn, err := net.Dial("tcp", root.Addr().String())
+		a.NoError(err)
+		defer conn.Close()
+
+		request := "GET / HTTP/1.0\r\n\r\n"
+		_, err = conn.Write([]byte(request))
+		a.NoError(err)
+
+		accepted, err := shared.HTTP().Accept()
+		a.NoError(err)
+		defer accepted.Close()
+
+		line, err := bufio.NewReader(accepted).ReadString('\n')
+		a.NoError(err)
+		a.Equal("GET / HTTP/1.0\r\n", line)
+	})
+
+	t.Run("should route gRPC connections", func(t *testing.T) {
+		conn, err := grpc.Dial(root.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		a.NoError(err)
+		defer conn.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		res, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+		a.NoError(err)
+		a.Equal(healthpb.HealthCheckResponse_SERVING, res.GetStatus())
+	})
+
+	t.Run("should close the root listener with its routed listeners", func(t *testing.T) {
+		server.Stop()
+		a.NoError(shared.HTTP().Close())
+
+		_, err := shared.HTTP().Accept()
+		a.ErrorIs(err, net.ErrClosed)
+
+		a.Eventually(func() bool {
+			_, err := net.DialTimeout("tcp", root.Addr().String(), time.Second)
+			return err != nil
+		}, time.Second, 10*time.Millisecond)
+	})
+}
@@ -0,0 +1,67 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileProvider is a built-in secrets provider that loads secrets from files,
+// one per secret, inside a directory, such as the '/run/secrets' used by
+// docker and kubernetes.
+type FileProvider struct {
+	path string
+}
+
+// NewFileProvider creates a provider that loads secrets from files inside
+// path, using the secret name as the file name.
+func NewFileProvider(path string) *FileProvider {
+	return &FileProvider{
+		path: path,
+	}
+}
+
+func (f *FileProvider) Secret(_ context.Context, name string) (string, bool, error) {
+	if f.path == "" {
+		return "", false, nil
+	}
+
+	if !filepath.IsLocal(name) {
+		return "", false, fmt.Errorf("invalid secret name '%s'", name)
+	}
+
+	data, err := os.ReadFile(filepath.Join(f.path, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+// EnvProvider is a built-in secrets provider that loads secrets from
+// environment variables. A secret name is converted to its variable name by
+// using upper case letters and replacing '-', '.' and '/' by '_', so the
+// secret 'db-password' is loaded from DB_PASSWORD.
+type EnvProvider struct{}
+
+// NewEnvProvider creates a provider that loads secrets from environment
+// variables.
+func NewEnvProvider() *EnvProvider {
+	return &EnvProvider{}
+}
+
+func (e *EnvProvider) Secret(_ context.Context, name string) (string, bool, error) {
+	value, ok := os.LookupEnv(envName(name))
+	return value, ok, nil
+}
+
+func envName(name string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_", "/", "_").Replace(name))
+}
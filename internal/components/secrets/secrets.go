@@ -0,0 +1,176 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	loggerApi "github.com/somatech1/mikros/apis/logger"
+	secretsApi "github.com/somatech1/mikros/apis/secrets"
+	"github.com/somatech1/mikros/components/logger"
+)
+
+const (
+	maskedValue = "******"
+
+	// minEmbeddedLength is the length that a resolved value must have to be
+	// searched inside other values. Shorter ones, like "1", "true" or "dev",
+	// are only masked when they are the whole value of an attribute, since
+	// they are too common to be replaced everywhere.
+	minEmbeddedLength = 6
+)
+
+// Secrets gathers all secret providers available for a service, searching
+// for a secret in the ones registered by the service before the built-in
+// ones. It also keeps track of every resolved value, and of the keys that
+// hold them, so they can be masked when written somewhere.
+type Secrets struct {
+	mu       sync.RWMutex
+	builtin  []secretsApi.Provider
+	external []secretsApi.Provider
+	values   map[string]struct{}
+	keys     map[string]struct{}
+}
+
+// New creates a new Secrets with the built-in providers: secrets stored as
+// files inside path and secrets stored as environment variables, in this
+// order.
+func New(path string) *Secrets {
+	return &Secrets{
+		builtin: []secretsApi.Provider{
+			NewFileProvider(path),
+			NewEnvProvider(),
+		},
+		values: make(map[string]struct{}),
+		keys:   make(map[string]struct{}),
+	}
+}
+
+// Register adds external providers, which are searched in the order they
+// were registered and before the built-in ones.
+func (s *Secrets) Register(providers ...secretsApi.Provider) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.external = append(s.external, providers...)
+}
+
+// Secret retrieves the value of a secret from the first provider that has it.
+// The secret name is also tracked as a key holding a secret, since secrets
+// loaded by env.Load are named after their variables.
+func (s *Secrets) Secret(ctx context.Context, name string) (string, bool, error) {
+	s.mu.RLock()
+	providers := append(append([]secretsApi.Provider{}, s.external...), s.builtin...)
+	s.mu.RUnlock()
+
+	for _, p := range providers {
+		value, ok, err := p.Secret(ctx, name)
+		if err != nil {
+			return "", false, fmt.Errorf("could not retrieve secret '%s': %w", name, err)
+		}
+		if ok {
+			s.mu.Lock()
+			if value != "" {
+				s.values[value] = struct{}{}
+			}
+			s.keys[normalizeKey(name)] = struct{}{}
+			s.mu.Unlock()
+
+			return value, true, nil
+		}
+	}
+
+	return "", false, nil
+}
+
+// Resolve retrieves the value of a secret, failing if no provider has it.
+func (s *Secrets) Resolve(ctx context.Context, name string) (string, error) {
+	value, ok, err := s.Secret(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("secret '%s' not found", name)
+	}
+
+	return value, nil
+}
+
+// TrackKey records a key, like a definitions one, whose value came from a
+// secret, so attributes with the same key are always masked.
+func (s *Secrets) TrackKey(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys[normalizeKey(key)] = struct{}{}
+}
+
+// MaskAttributes gives a copy of log attributes with all secret values
+// masked. An attribute has its whole value masked when its key holds a
+// secret or when its value is a resolved secret. Resolved secrets found
+// inside other values are masked too.
+func (s *Secrets) MaskAttributes(attrs []loggerApi.Attribute) []loggerApi.Attribute {
+	masked := make([]loggerApi.Attribute, len(attrs))
+	for i, attr := range attrs {
+		masked[i] = attr
+
+		var value string
+		switch v := attr.Value().(type) {
+		case string:
+			value = v
+		case fmt.Stringer:
+			value = v.String()
+		default:
+			value = fmt.Sprintf("%v", v)
+		}
+
+		if m := s.mask(attr.Key(), value); m != value {
+			masked[i] = logger.String(attr.Key(), m)
+		}
+	}
+
+	return masked
+}
+
+func (s *Secrets) mask(key, value string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.values[value]; ok || s.isSecretKey(key) {
+		return maskedValue
+	}
+
+	for secret := range s.values {
+		if len(secret) >= minEmbeddedLength {
+			value = strings.ReplaceAll(value, secret, maskedValue)
+		}
+	}
+
+	return value
+}
+
+// isSecretKey checks if key, or the end of it, is a key that holds a secret,
+// so the attribute 'database.password' matches the definitions key
+// 'features.database.password' and the variable DATABASE_PASSWORD.
+func (s *Secrets) isSecretKey(key string) bool {
+	key = normalizeKey(key)
+	if key == "" {
+		return false
+	}
+
+	for k := range s.keys {
+		if k == key || strings.HasSuffix(k, "."+key) || strings.HasSuffix(key, "."+k) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalizeKey gives a key using the same separator for definitions keys,
+// secret names and environment variables.
+func normalizeKey(key string) string {
+	key = strings.NewReplacer("-", ".", "_", ".", "/", ".", "[", ".", "]", "").Replace(key)
+	return strings.ToLower(key)
+}
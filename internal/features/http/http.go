@@ -6,6 +6,7 @@ import (
 
 	"github.com/valyala/fasthttp"
 
+	httpApi "github.com/somatech1/mikros/apis/http"
 	loggerApi "github.com/somatech1/mikros/apis/logger"
 	"github.com/somatech1/mikros/components/definition"
 	"github.com/somatech1/mikros/components/plugin"
@@ -50,6 +51,16 @@ func (c *Client) SetResponseCode(ctx context.Context, code int) {
 	}
 }
 
+func (c *Client) SetResponseError(ctx context.Context, err error) {
+	if !c.IsEnabled() {
+		return
+	}
+
+	if c, ok := ctx.(*fasthttp.RequestCtx); ok {
+		c.SetUserValue(httpApi.ResponseErrorKey, err)
+	}
+}
+
 func (c *Client) Fields() []loggerApi.Attribute {
 	return []loggerApi.Attribute{}
 }
@@ -0,0 +1,45 @@
+package grpc
+
+import (
+	"github.com/somatech1/mikros/components/definition"
+)
+
+type Definitions struct {
+	LogRequests bool `toml:"log_requests,omitempty" default:"false" json:"log_requests"`
+}
+
+func newDefinitions(definitions *definition.Definitions, instance string) (*Definitions, error) {
+	section, err := definitions.InstanceSection(definition.ServiceInstance{
+		Type: definition.ServiceType_gRPC,
+		Name: instance,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeDefinitions(section)
+}
+
+func decodeDefinitions(section *definition.Section) (*Definitions, error) {
+	var defs Definitions
+	if err := section.Decode(&defs); err != nil {
+		return nil, err
+	}
+
+	return &defs, nil
+}
+
+func (d *Definitions) Name() string {
+	return definition.ServiceType_gRPC.String()
+}
+
+func (d *Definitions) Validate() error {
+	// Already validated by the section decoding.
+	return nil
+}
+
+// DecodeDefinitions loads the gRPC service definitions, so they can be
+// validated before the service is initialized.
+func (s *Server) DecodeDefinitions(section *definition.Section) (definition.ExternalServiceEntry, error) {
+	return decodeDefinitions(section)
+}
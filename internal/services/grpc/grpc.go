@@ -10,26 +10,32 @@ import (
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	grpc_recovery "github.com/grpc-ecosystem/go-grpc-middleware/recovery"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/health"
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 
 	errorsApi "github.com/somatech1/mikros/apis/errors"
 	loggerApi "github.com/somatech1/mikros/apis/logger"
+	tracingApi "github.com/somatech1/mikros/apis/tracing"
+	trackerApi "github.com/somatech1/mikros/apis/tracker"
 	"github.com/somatech1/mikros/components/definition"
 	"github.com/somatech1/mikros/components/logger"
 	"github.com/somatech1/mikros/components/options"
 	"github.com/somatech1/mikros/components/plugin"
-	"github.com/somatech1/mikros/components/service"
+	"github.com/somatech1/mikros/internal/components/listener"
 )
 
 type Server struct {
-	port             service.ServerPort
-	server           *grpc.Server
-	listener         net.Listener
-	health           *health.Server
-	errors           errorsApi.ErrorFactory
-	protoServiceDesc *grpc.ServiceDesc
+	instance          string
+	defs              *Definitions
+	logger            loggerApi.Logger
+	tracing           tracingApi.Tracer
+	tracker           trackerApi.Tracker
+	trackerHeaderName string
+	server            *grpc.Server
+	listener          net.Listener
+	health            *healthServer
+	errors            errorsApi.ErrorFactory
+	protoServiceDesc  *grpc.ServiceDesc
 }
 
 func New() *Server {
@@ -41,10 +47,27 @@ func (s *Server) Name() string {
 }
 
 func (s *Server) Info() []loggerApi.Attribute {
-	return []loggerApi.Attribute{
-		logger.String("service.address", fmt.Sprintf(":%v", s.port.Int32())),
+	attrs := []loggerApi.Attribute{
+		logger.String("service.address", s.listener.Addr().String()),
 		logger.String("service.mode", definition.ServiceType_gRPC.String()),
 	}
+
+	if s.instance != "" {
+		attrs = append(attrs, logger.String("service.instance", s.instance))
+	}
+
+	return attrs
+}
+
+// Addr gives the address where the server is listening for connections.
+func (s *Server) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// NewInstance creates a new server, allowing the service to have more than
+// one named instance of it.
+func (s *Server) NewInstance() plugin.Service {
+	return New()
 }
 
 func (s *Server) Run(_ context.Context, srv interface{}) error {
@@ -63,38 +86,65 @@ func (s *Server) Initialize(_ context.Context, opt *plugin.ServiceOptions) error
 		return err
 	}
 
-	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", opt.Port))
-	if err != nil {
-		return fmt.Errorf("could not listen to service port: %w", err)
-	}
-
 	svc, ok := opt.Service.(*options.GrpcServiceOptions)
 	if !ok {
 		return errors.New("unsupported ServiceOptions received on initialization")
 	}
 
+	// Initialize specific service definitions
+	defs, err := newDefinitions(opt.Definitions, opt.Instance)
+	if err != nil {
+		return err
+	}
+
+	l, err := listener.Listen(opt)
+	if err != nil {
+		return err
+	}
+
+	s.defs = defs
 	s.errors = opt.Errors
-	s.listener = listener
+	s.logger = opt.Logger
+	s.tracing = s.getTracing(opt)
+	s.tracker = s.getTracker(opt)
+	s.trackerHeaderName = opt.Env.TrackerHeaderName()
+	s.listener = l
 	s.protoServiceDesc = svc.ProtoServiceDescription
-	s.port = opt.Port
+	s.instance = opt.Instance
 
 	// Starts the gRPC server
-	s.server = grpc.NewServer(
+	s.server = s.newServer()
+
+	s.health = newHealthServer(opt.Health, s.protoServiceDesc.ServiceName)
+	healthpb.RegisterHealthServer(s.server, s.health)
+
+	return nil
+}
+
+// newServer creates the gRPC server with all of its interceptors. The
+// recovery ones come first so that panics from the other interceptors are
+// also recovered.
+func (s *Server) newServer() *grpc.Server {
+	return grpc.NewServer(
 		grpc.ChainUnaryInterceptor(
 			grpc_middleware.ChainUnaryServer(
 				grpc_recovery.UnaryServerInterceptor(
 					grpc_recovery.WithRecoveryHandlerContext(s.recoverFromGrpcPanic),
 				),
+				s.contextUnaryInterceptor,
+				s.measureUnaryInterceptor,
+			),
+		),
+		grpc.ChainStreamInterceptor(
+			grpc_middleware.ChainStreamServer(
+				grpc_recovery.StreamServerInterceptor(
+					grpc_recovery.WithRecoveryHandlerContext(s.recoverFromGrpcPanic),
+				),
+				s.contextStreamInterceptor,
+				s.measureStreamInterceptor,
 			),
 		),
 	)
-
-	healthSrv := health.NewServer()
-	healthSrv.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
-	healthpb.RegisterHealthServer(s.server, healthSrv)
-	s.health = healthSrv
-
-	return nil
 }
 
 func (s *Server) recoverFromGrpcPanic(ctx context.Context, p interface{}) error {
@@ -108,7 +158,7 @@ func (s *Server) validate(opt *plugin.ServiceOptions) error {
 			opt.Service,
 			opt.Logger,
 			opt.Errors,
-			opt.Port,
+			opt.Health,
 		}
 	)
 
@@ -121,7 +171,28 @@ func (s *Server) validate(opt *plugin.ServiceOptions) error {
 	return nil
 }
 
-func (s *Server) Stop(_ context.Context) error {
-	// Nothing to do here
-	return nil
+// Stop gracefully stops the server, waiting for all in-flight RPCs to finish.
+// If the context deadline is reached before that, the server is forced to
+// stop, closing all open connections.
+func (s *Server) Stop(ctx context.Context) error {
+	// The server only closes the listener it is serving, so it must be
+	// closed here for servers stopped before running.
+	defer func() {
+		_ = s.listener.Close()
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+
+	case <-ctx.Done():
+		s.server.Stop()
+		return ctx.Err()
+	}
 }
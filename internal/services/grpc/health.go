@@ -0,0 +1,86 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	healthApi "github.com/somatech1/mikros/apis/health"
+)
+
+const (
+	// livenessServiceName is the service name that health checkers must use
+	// to check the service liveness.
+	livenessServiceName = "liveness"
+
+	// readinessServiceName is the service name that health checkers must use
+	// to check the service readiness. The empty name and the gRPC service
+	// name itself also give the service readiness.
+	readinessServiceName = "readiness"
+)
+
+// healthServer is a grpc_health_v1 implementation that answers Check requests
+// using the service health subsystem. Watch requests are handled by the
+// standard health server, which is updated every time the service lifecycle
+// state changes.
+type healthServer struct {
+	*health.Server
+	checker           healthApi.Checker
+	readinessServices []string
+}
+
+func newHealthServer(checker healthApi.Checker, serviceName string) *healthServer {
+	h := &healthServer{
+		Server:            health.NewServer(),
+		checker:           checker,
+		readinessServices: []string{"", readinessServiceName, serviceName},
+	}
+
+	h.SetServingStatus(livenessServiceName, healthpb.HealthCheckResponse_SERVING)
+	h.updateServingStatus(checker.State())
+	checker.OnStateChange(h.updateServingStatus)
+
+	return h
+}
+
+func (h *healthServer) updateServingStatus(state healthApi.State) {
+	servingStatus := healthpb.HealthCheckResponse_NOT_SERVING
+	if state == healthApi.StateReady {
+		servingStatus = healthpb.HealthCheckResponse_SERVING
+	}
+
+	for _, name := range h.readinessServices {
+		h.SetServingStatus(name, servingStatus)
+	}
+}
+
+func (h *healthServer) Check(ctx context.Context, in *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	check := h.checker.Readiness
+	if in.GetService() == livenessServiceName {
+		check = h.checker.Liveness
+	} else if !h.isReadinessService(in.GetService()) {
+		return nil, status.Error(codes.NotFound, "unknown service")
+	}
+
+	servingStatus := healthpb.HealthCheckResponse_SERVING
+	if err := check(ctx); err != nil {
+		servingStatus = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	return &healthpb.HealthCheckResponse{
+		Status: servingStatus,
+	}, nil
+}
+
+func (h *healthServer) isReadinessService(name string) bool {
+	for _, n := range h.readinessServices {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,149 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	tracingApi "github.com/somatech1/mikros/apis/tracing"
+	trackerApi "github.com/somatech1/mikros/apis/tracker"
+	mcontext "github.com/somatech1/mikros/components/context"
+	"github.com/somatech1/mikros/components/logger"
+	"github.com/somatech1/mikros/components/options"
+	"github.com/somatech1/mikros/components/plugin"
+)
+
+// contextUnaryInterceptor prepares the context of every unary RPC.
+func (s *Server) contextUnaryInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(s.rpcContext(ctx), req)
+}
+
+// contextStreamInterceptor prepares the context of every streaming RPC.
+func (s *Server) contextStreamInterceptor(srv interface{}, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	wrapped := grpc_middleware.WrapServerStream(stream)
+	wrapped.WrappedContext = s.rpcContext(stream.Context())
+
+	return handler(srv, wrapped)
+}
+
+// measureUnaryInterceptor traces and logs every unary RPC.
+func (s *Server) measureUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	var res interface{}
+	err := s.measure(ctx, info.FullMethod, func() error {
+		var err error
+		res, err = handler(ctx, req)
+		return err
+	})
+
+	return res, err
+}
+
+// measureStreamInterceptor traces and logs every streaming RPC, for its
+// whole duration.
+func (s *Server) measureStreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return s.measure(stream.Context(), info.FullMethod, func() error {
+		return handler(srv, stream)
+	})
+}
+
+// rpcContext puts into the RPC context the tracker ID received from the
+// caller, or a new one, when it did not send it, and the ServiceContext sent
+// by the caller. The tracker ID is also sent back to the caller inside the
+// response header.
+func (s *Server) rpcContext(ctx context.Context) context.Context {
+	if s.tracker != nil {
+		trackId, ok := s.tracker.Retrieve(ctx)
+		if !ok {
+			trackId = s.tracker.Generate()
+		}
+
+		ctx = s.tracker.Add(ctx, trackId)
+		if s.trackerHeaderName != "" {
+			_ = grpc.SetHeader(ctx, metadata.Pairs(strings.ToLower(s.trackerHeaderName), trackId))
+		}
+	}
+
+	if svcCtx, ok := mcontext.FromContext(ctx); ok {
+		ctx = mcontext.NewContext(ctx, svcCtx)
+	}
+
+	return ctx
+}
+
+// measure executes an RPC call measuring it with the tracing feature and
+// logging it, when enabled by the service definitions. Health checks are
+// not measured.
+func (s *Server) measure(ctx context.Context, method string, call func() error) error {
+	if isHealthCheck(method) {
+		return call()
+	}
+
+	var (
+		data  interface{}
+		start = time.Now()
+	)
+
+	if s.tracing != nil {
+		d, err := s.tracing.StartMeasurements(ctx, s.Name())
+		if err != nil {
+			s.logger.Error(ctx, "tracing begin failed", logger.Error(err))
+		}
+		data = d
+	}
+
+	callErr := call()
+
+	if s.tracing != nil {
+		if err := s.tracing.ComputeMetrics(ctx, s.Name(), data); err != nil {
+			s.logger.Error(ctx, "tracing cease failed", logger.Error(err))
+		}
+	}
+
+	if s.defs.LogRequests {
+		s.logger.Info(ctx, "rpc",
+			logger.String("grpc.method", method),
+			logger.String("grpc.code", status.Code(callErr).String()),
+			logger.String("grpc.latency", time.Since(start).String()),
+		)
+	}
+
+	return callErr
+}
+
+func isHealthCheck(method string) bool {
+	return strings.HasPrefix(method, "/"+healthpb.Health_ServiceDesc.ServiceName+"/")
+}
+
+func (s *Server) getTracing(opt *plugin.ServiceOptions) tracingApi.Tracer {
+	t, err := opt.Features.Feature(options.TracingFeatureName)
+	if err != nil {
+		return nil
+	}
+
+	api, ok := t.(plugin.FeatureInternalAPI)
+	if !ok {
+		return nil
+	}
+
+	return api.FrameworkAPI().(tracingApi.Tracer)
+}
+
+func (s *Server) getTracker(opt *plugin.ServiceOptions) trackerApi.Tracker {
+	t, err := opt.Features.Feature(options.TrackerFeatureName)
+	if err != nil {
+		return nil
+	}
+
+	api, ok := t.(plugin.FeatureInternalAPI)
+	if !ok {
+		return nil
+	}
+
+	return api.FrameworkAPI().(trackerApi.Tracker)
+}
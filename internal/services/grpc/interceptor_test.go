@@ -0,0 +1,119 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	merrors "github.com/somatech1/mikros/internal/components/errors"
+	"github.com/somatech1/mikros/internal/components/logger"
+)
+
+// panicService is a service whose handlers always panic.
+var panicService = &grpc.ServiceDesc{
+	ServiceName: "example.Service",
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Unary",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := new(emptypb.Empty)
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+
+				info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/example.Service/Unary"}
+				return interceptor(ctx, in, info, func(_ context.Context, _ interface{}) (interface{}, error) {
+					panic("unary handler")
+				})
+			},
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Stream",
+			ServerStreams: true,
+			Handler: func(_ interface{}, _ grpc.ServerStream) error {
+				panic("stream handler")
+			},
+		},
+	},
+}
+
+// panicTracer is a tracer that panics when measuring calls.
+type panicTracer struct{}
+
+func (panicTracer) StartMeasurements(_ context.Context, _ string) (interface{}, error) {
+	panic("tracer")
+}
+
+func (panicTracer) ComputeMetrics(_ context.Context, _ string, _ interface{}) error {
+	return nil
+}
+
+func newPanicTestConn(t *testing.T, s *Server) *grpc.ClientConn {
+	l := bufconn.Listen(1024 * 1024)
+	log := logger.New(logger.Options{LogOnlyFatalLevel: true})
+
+	s.defs = &Definitions{}
+	s.logger = log
+	s.errors = merrors.NewFactory(merrors.FactoryOptions{ServiceName: "example", Logger: log})
+	s.server = s.newServer()
+	s.server.RegisterService(panicService, struct{}{})
+
+	go func() {
+		_ = s.server.Serve(l)
+	}()
+	t.Cleanup(s.server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return l.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	return conn
+}
+
+func TestServerRecovery(t *testing.T) {
+	a := assert.New(t)
+
+	for name, s := range map[string]*Server{
+		"handlers":     {},
+		"interceptors": {tracing: panicTracer{}},
+	} {
+		t.Run("should recover from panics in "+name, func(t *testing.T) {
+			conn := newPanicTestConn(t, s)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			err := conn.Invoke(ctx, "/example.Service/Unary", &emptypb.Empty{}, &emptypb.Empty{})
+			a.Equal(codes.Internal, status.Code(err))
+
+			stream, err := conn.NewStream(ctx, &panicService.Streams[0], "/example.Service/Stream")
+			a.NoError(err)
+			a.NoError(stream.CloseSend())
+			err = stream.RecvMsg(&emptypb.Empty{})
+			a.Equal(codes.Internal, status.Code(err))
+
+			// The server must still be serving after the panics.
+			err = conn.Invoke(ctx, "/example.Service/Unary", &emptypb.Empty{}, &emptypb.Empty{})
+			a.Equal(codes.Internal, status.Code(err))
+		})
+	}
+}
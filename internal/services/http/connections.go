@@ -0,0 +1,63 @@
+package http
+
+import (
+	"net"
+	"sync"
+
+	"github.com/valyala/fasthttp"
+)
+
+// connections keeps the connections open by the server, so they can be
+// closed when it does not stop before its deadline.
+type connections struct {
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+}
+
+func newConnections() *connections {
+	return &connections{
+		conns: make(map[net.Conn]struct{}),
+	}
+}
+
+// track follows the state of the server connections. Hijacked connections
+// belong to their handlers, so they are not tracked anymore.
+func (c *connections) track(conn net.Conn, state fasthttp.ConnState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch state {
+	case fasthttp.StateNew:
+		c.conns[conn] = struct{}{}
+	case fasthttp.StateHijacked, fasthttp.StateClosed:
+		delete(c.conns, conn)
+	}
+}
+
+// closeAll closes every connection that is still open.
+//
+// Connections are shut down instead of closed when possible, since the
+// server panics if it cannot set the deadlines of a connection that it is
+// still serving. It closes them by itself once they fail.
+func (c *connections) closeAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for conn := range c.conns {
+		if s, ok := conn.(halfCloser); ok {
+			_ = s.CloseRead()
+			_ = s.CloseWrite()
+		} else {
+			_ = conn.Close()
+		}
+
+		delete(c.conns, conn)
+	}
+}
+
+// halfCloser is a connection, like TCP and unix ones, that can be shut down
+// without releasing it.
+type halfCloser interface {
+	CloseRead() error
+	CloseWrite() error
+}
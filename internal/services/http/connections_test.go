@@ -0,0 +1,93 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+func TestServerStop(t *testing.T) {
+	a := assert.New(t)
+
+	newServer := func(t *testing.T, handler fasthttp.RequestHandler) (*Server, net.Conn) {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+
+		s := &Server{listener: l}
+		s.server = s.newHttpServer(handler)
+		go func() {
+			_ = s.server.Serve(l)
+		}()
+
+		conn, err := net.Dial("tcp", l.Addr().String())
+		assert.NoError(t, err)
+		t.Cleanup(func() {
+			_ = conn.Close()
+		})
+
+		return s, conn
+	}
+
+	t.Run("should close in-flight connections at the deadline", func(t *testing.T) {
+		var (
+			started = make(chan struct{})
+			release = make(chan struct{})
+		)
+		defer close(release)
+
+		s, conn := newServer(t, func(_ *fasthttp.RequestCtx) {
+			close(started)
+			<-release
+		})
+
+		_, err := conn.Write([]byte("GET / HTTP/1.1\r\nHost: example\r\n\r\n"))
+		a.NoError(err)
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		a.ErrorIs(s.Stop(ctx), context.DeadlineExceeded)
+
+		// The connection was closed by the server, without any response.
+		a.NoError(conn.SetReadDeadline(time.Now().Add(5 * time.Second)))
+		_, err = bufio.NewReader(conn).ReadByte()
+		a.Error(err)
+		a.False(isTimeout(err))
+	})
+
+	t.Run("should close keep-alive connections", func(t *testing.T) {
+		s, conn := newServer(t, func(ctx *fasthttp.RequestCtx) {
+			ctx.SetStatusCode(fasthttp.StatusOK)
+		})
+
+		_, err := conn.Write([]byte("GET / HTTP/1.1\r\nHost: example\r\n\r\n"))
+		a.NoError(err)
+
+		var res fasthttp.Response
+		r := bufio.NewReader(conn)
+		a.NoError(res.Read(r))
+		a.Equal(fasthttp.StatusOK, res.StatusCode())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		a.NoError(s.Stop(ctx))
+
+		a.NoError(conn.SetReadDeadline(time.Now().Add(5 * time.Second)))
+		_, err = r.ReadByte()
+		a.Error(err)
+		a.False(isTimeout(err))
+	})
+}
+
+// isTimeout checks if reading a connection failed because of its deadline,
+// i.e., it was still open.
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -1,10 +1,6 @@
 package http
 
 import (
-	"encoding/json"
-
-	"github.com/creasty/defaults"
-
 	"github.com/somatech1/mikros/components/definition"
 )
 
@@ -13,19 +9,38 @@ type Definitions struct {
 	DisablePanicRecovery bool `toml:"disable_panic_recovery,omitempty" default:"false" json:"disable_panic_recovery"`
 }
 
-func newDefinitions(definitions *definition.Definitions) *Definitions {
-	if currentDefs, ok := definitions.LoadService(definition.ServiceType_HTTP); ok {
-		if b, err := json.Marshal(currentDefs); err == nil {
-			var serviceDefs Definitions
-			if err := json.Unmarshal(b, &serviceDefs); err == nil {
-				return &serviceDefs
-			}
-		}
+func newDefinitions(definitions *definition.Definitions, instance string) (*Definitions, error) {
+	section, err := definitions.InstanceSection(definition.ServiceInstance{
+		Type: definition.ServiceType_HTTP,
+		Name: instance,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeDefinitions(section)
+}
+
+func decodeDefinitions(section *definition.Section) (*Definitions, error) {
+	var defs Definitions
+	if err := section.Decode(&defs); err != nil {
+		return nil, err
 	}
 
-	// Use the default values
-	defs := &Definitions{}
-	_ = defaults.Set(defs)
+	return &defs, nil
+}
+
+func (d *Definitions) Name() string {
+	return definition.ServiceType_HTTP.String()
+}
+
+func (d *Definitions) Validate() error {
+	// Already validated by the section decoding.
+	return nil
+}
 
-	return defs
+// DecodeDefinitions loads the HTTP service definitions, so they can be
+// validated before the service is initialized.
+func (s *Server) DecodeDefinitions(section *definition.Section) (definition.ExternalServiceEntry, error) {
+	return decodeDefinitions(section)
 }
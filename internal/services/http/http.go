@@ -12,9 +12,11 @@ import (
 	"github.com/lab259/cors"
 	"github.com/valyala/fasthttp"
 
+	healthApi "github.com/somatech1/mikros/apis/health"
 	"github.com/somatech1/mikros/apis/http_auth"
 	"github.com/somatech1/mikros/apis/http_cors"
 	"github.com/somatech1/mikros/apis/http_panic_recovery"
+	"github.com/somatech1/mikros/apis/http_problem"
 	loggerApi "github.com/somatech1/mikros/apis/logger"
 	tracingApi "github.com/somatech1/mikros/apis/tracing"
 	trackerApi "github.com/somatech1/mikros/apis/tracker"
@@ -22,19 +24,24 @@ import (
 	"github.com/somatech1/mikros/components/logger"
 	"github.com/somatech1/mikros/components/options"
 	"github.com/somatech1/mikros/components/plugin"
-	"github.com/somatech1/mikros/components/service"
+	"github.com/somatech1/mikros/internal/components/listener"
 )
 
 type Server struct {
-	port              service.ServerPort
+	instance          string
 	trackerHeaderName string
 	defs              *Definitions
 	server            *fasthttp.Server
+	conns             *connections
 	listener          net.Listener
 	logger            loggerApi.Logger
 	tracing           tracingApi.Tracer
 	tracker           trackerApi.Tracker
 	panicRecovery     http_panic_recovery.Recovery
+	problemRenderer   http_problem.Renderer
+	hideErrorDetails  bool
+	health            healthApi.Checker
+	info              []byte
 }
 
 func New() *Server {
@@ -46,19 +53,46 @@ func (s *Server) Name() string {
 }
 
 func (s *Server) Info() []loggerApi.Attribute {
-	return []loggerApi.Attribute{
-		logger.String("service.address", fmt.Sprintf(":%v", s.port.Int32())),
+	attrs := []loggerApi.Attribute{
+		logger.String("service.address", s.listener.Addr().String()),
 		logger.String("service.mode", definition.ServiceType_HTTP.String()),
 		logger.String("service.http_auth", fmt.Sprintf("%t", !s.defs.DisableAuth)),
 	}
+
+	if s.instance != "" {
+		attrs = append(attrs, logger.String("service.instance", s.instance))
+	}
+
+	return attrs
+}
+
+// Addr gives the address where the server is listening for connections.
+func (s *Server) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// NewInstance creates a new server, allowing the service to have more than
+// one named instance of it.
+func (s *Server) NewInstance() plugin.Service {
+	return New()
 }
 
 func (s *Server) Run(_ context.Context, _ interface{}) error {
 	return s.server.Serve(s.listener)
 }
 
-func (s *Server) Stop(_ context.Context) error {
-	return s.server.Shutdown()
+// Stop gracefully stops the server, waiting for all open connections to be
+// closed until the context deadline is reached, when the remaining ones are
+// forcibly closed. Its listener is also closed, since the server may have
+// been stopped before running.
+func (s *Server) Stop(ctx context.Context) error {
+	err := s.server.ShutdownWithContext(ctx)
+	if err != nil && ctx.Err() != nil {
+		s.conns.closeAll()
+	}
+	_ = s.listener.Close()
+
+	return err
 }
 
 func (s *Server) Initialize(ctx context.Context, opt *plugin.ServiceOptions) error {
@@ -67,25 +101,45 @@ func (s *Server) Initialize(ctx context.Context, opt *plugin.ServiceOptions) err
 	}
 
 	// Initialize specific service definitions
-	s.defs = newDefinitions(opt.Definitions)
-
-	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", opt.Port))
+	defs, err := newDefinitions(opt.Definitions, opt.Instance)
 	if err != nil {
-		return fmt.Errorf("could not listen to service port: %w", err)
+		return err
 	}
+	s.defs = defs
 
 	if err := s.initializeHttpServerInternals(ctx, opt); err != nil {
 		return err
 	}
 
-	s.listener = listener
-	s.port = opt.Port
+	s.instance = opt.Instance
 	s.logger = opt.Logger
 	s.tracing = s.getTracing(opt)
 	s.tracker = s.getTracker(opt)
 	s.trackerHeaderName = opt.Env.TrackerHeaderName()
+	s.health = opt.Health
+
+	if plugin.DeploymentTraits(opt.Env).DebugEndpoints {
+		info, err := newServiceInfo(opt)
+		if err != nil {
+			return err
+		}
+		s.info = info
+	}
 
 	s.panicRecovery = s.getPanicRecovery(opt)
+	s.problemRenderer = s.getProblemRenderer(opt)
+
+	// Error details may expose internal information, so they are not sent
+	// in production-like environments.
+	s.hideErrorDetails = plugin.DeploymentTraits(opt.Env).ProductionLike
+
+	// The listener is created only at the end so that it is not left open
+	// when the initialization fails.
+	l, err := listener.Listen(opt)
+	if err != nil {
+		return err
+	}
+	s.listener = l
 
 	return nil
 }
@@ -96,10 +150,10 @@ func (s *Server) validate(opt *plugin.ServiceOptions) error {
 		fields   = []interface{}{
 			opt.Name,
 			opt.Logger,
-			opt.Port,
-			opt.Env.DeploymentEnv(),
+			plugin.DeploymentEnvName(opt.Env),
 			opt.Service,
 			opt.Features,
+			opt.Health,
 		}
 	)
 
@@ -193,10 +247,18 @@ func (s *Server) registerHttpServer(handler fasthttp.RequestHandler, opt *plugin
 		handler = cors.New(serverCors.Cors()).Handler(handler)
 	}
 
-	s.server = &fasthttp.Server{
+	s.server = s.newHttpServer(handler)
+}
+
+func (s *Server) newHttpServer(handler fasthttp.RequestHandler) *fasthttp.Server {
+	s.conns = newConnections()
+
+	return &fasthttp.Server{
 		NoDefaultServerHeader: true,
+		CloseOnShutdown:       true,
 		Handler:               handler,
 		ErrorHandler:          s.handleHTTPError,
+		ConnState:             s.conns.track,
 		ReadTimeout:           60 * time.Second,
 		WriteTimeout:          60 * time.Second,
 		ReadBufferSize:        64 * 1024,
@@ -248,8 +310,7 @@ func (s *Server) serverRequestHandler(h fasthttp.RequestHandler) fasthttp.Reques
 			ctx.Response.Header.Set(s.trackerHeaderName, trackId)
 		}
 
-		if ctx.IsGet() && string(ctx.Path()) == "/health" {
-			ctx.SetStatusCode(fasthttp.StatusOK)
+		if ctx.IsGet() && (s.handleHealthCheck(ctx) || s.handleInfo(ctx)) {
 			return
 		}
 
@@ -267,6 +328,7 @@ func (s *Server) serverRequestHandler(h fasthttp.RequestHandler) fasthttp.Reques
 		}
 
 		h(ctx)
+		s.handleResponseError(ctx)
 
 		if s.tracing != nil {
 			if err := s.tracing.ComputeMetrics(ctx, s.Name(), data); err != nil {
@@ -276,6 +338,43 @@ func (s *Server) serverRequestHandler(h fasthttp.RequestHandler) fasthttp.Reques
 	}
 }
 
+// handleHealthCheck answers the request if it is a health check one, returning
+// true in this case. The '/health' endpoint is kept as an alias for the
+// readiness check.
+func (s *Server) handleHealthCheck(ctx *fasthttp.RequestCtx) bool {
+	var check func(ctx context.Context) error
+
+	switch string(ctx.Path()) {
+	case "/health/live":
+		check = s.health.Liveness
+	case "/health/ready", "/health":
+		check = s.health.Readiness
+	default:
+		return false
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	if err := check(ctx); err != nil {
+		ctx.SetStatusCode(fasthttp.StatusServiceUnavailable)
+	}
+
+	return true
+}
+
+// handleInfo answers the request if it is for the '/info' endpoint, which
+// is only available when the deployment environment allows debug endpoints.
+func (s *Server) handleInfo(ctx *fasthttp.RequestCtx) bool {
+	if s.info == nil || string(ctx.Path()) != "/info" {
+		return false
+	}
+
+	ctx.SetStatusCode(fasthttp.StatusOK)
+	ctx.SetContentType("application/json")
+	ctx.SetBody(s.info)
+
+	return true
+}
+
 func (s *Server) handleHTTPError(ctx *fasthttp.RequestCtx, err error) {
 	s.logger.Error(ctx, "http error", logger.Error(err))
 }
@@ -0,0 +1,25 @@
+package http
+
+import (
+	"encoding/json"
+
+	"github.com/somatech1/mikros/components/plugin"
+	"github.com/somatech1/mikros/components/service"
+)
+
+// serviceInfo is the content served by the '/info' endpoint.
+type serviceInfo struct {
+	Name    string             `json:"name"`
+	Version string             `json:"version"`
+	Env     string             `json:"env"`
+	Build   *service.BuildInfo `json:"build,omitempty"`
+}
+
+func newServiceInfo(opt *plugin.ServiceOptions) ([]byte, error) {
+	return json.Marshal(&serviceInfo{
+		Name:    opt.Name.String(),
+		Version: opt.Definitions.Version,
+		Env:     plugin.DeploymentEnvName(opt.Env),
+		Build:   opt.BuildInfo,
+	})
+}
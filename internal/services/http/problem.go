@@ -0,0 +1,88 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/valyala/fasthttp"
+
+	errorsApi "github.com/somatech1/mikros/apis/errors"
+	httpApi "github.com/somatech1/mikros/apis/http"
+	"github.com/somatech1/mikros/apis/http_problem"
+	"github.com/somatech1/mikros/components/logger"
+	"github.com/somatech1/mikros/components/options"
+	"github.com/somatech1/mikros/components/plugin"
+	merrors "github.com/somatech1/mikros/internal/components/errors"
+)
+
+const (
+	problemContentType = "application/problem+json"
+)
+
+// handleResponseError writes, when the handler returned an error, the
+// response as an RFC 7807 problem.
+func (s *Server) handleResponseError(ctx *fasthttp.RequestCtx) {
+	err, ok := ctx.UserValue(httpApi.ResponseErrorKey).(error)
+	if !ok || err == nil {
+		return
+	}
+
+	problem := newProblem(err, string(ctx.Path()), s.hideErrorDetails)
+	if s.problemRenderer != nil {
+		s.problemRenderer.Render(ctx, problem, err)
+		return
+	}
+
+	body, jerr := json.Marshal(problem)
+	if jerr != nil {
+		s.logger.Error(ctx, "could not encode HTTP problem", logger.Error(jerr))
+		ctx.SetStatusCode(fasthttp.StatusInternalServerError)
+		return
+	}
+
+	ctx.SetStatusCode(problem.Status)
+	ctx.SetContentType(problemContentType)
+	ctx.SetBody(body)
+}
+
+// newProblem converts a handler error into a problem. Errors that are not
+// framework errors are handled as internal errors.
+func newProblem(err error, instance string, hideDetails bool) *http_problem.Problem {
+	var e *merrors.Error
+	if !errors.As(err, &e) {
+		e = &merrors.Error{
+			Kind:          errorsApi.KindInternal,
+			Message:       "got an internal error",
+			SubLevelError: err.Error(),
+		}
+	}
+
+	problem := &http_problem.Problem{
+		Title:       e.Message,
+		Status:      e.HTTPStatus(),
+		Instance:    instance,
+		Code:        e.Code,
+		Kind:        e.Kind.String(),
+		ServiceName: e.ServiceName,
+	}
+
+	if !hideDetails {
+		problem.Detail = e.SubLevelError
+	}
+
+	return problem
+}
+
+func (s *Server) getProblemRenderer(opt *plugin.ServiceOptions) http_problem.Renderer {
+	p, err := opt.Features.Feature(options.HttpProblemFeatureName)
+	if err != nil {
+		return nil
+	}
+
+	api, ok := p.(plugin.FeatureInternalAPI)
+	if !ok {
+		return nil
+	}
+
+	return api.FrameworkAPI().(http_problem.Renderer)
+}
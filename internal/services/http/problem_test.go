@@ -0,0 +1,65 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+
+	errorsApi "github.com/somatech1/mikros/apis/errors"
+	httpApi "github.com/somatech1/mikros/apis/http"
+	"github.com/somatech1/mikros/apis/http_problem"
+	merrors "github.com/somatech1/mikros/internal/components/errors"
+)
+
+func TestHandleResponseError(t *testing.T) {
+	a := assert.New(t)
+	serviceErr := &merrors.Error{
+		Code:          42,
+		ServiceName:   "example",
+		Message:       "not found",
+		Kind:          errorsApi.KindNotFound,
+		SubLevelError: "record 1 does not exist",
+	}
+
+	handle := func(s *Server, err error) (*fasthttp.RequestCtx, *http_problem.Problem) {
+		var ctx fasthttp.RequestCtx
+		ctx.Request.SetRequestURI("/records/1")
+		ctx.SetUserValue(httpApi.ResponseErrorKey, err)
+		s.handleResponseError(&ctx)
+
+		var problem http_problem.Problem
+		a.NoError(json.Unmarshal(ctx.Response.Body(), &problem))
+		return &ctx, &problem
+	}
+
+	t.Run("should write framework errors as problems", func(t *testing.T) {
+		ctx, problem := handle(&Server{}, fmt.Errorf("wrapped: %w", serviceErr))
+		a.Equal(fasthttp.StatusNotFound, ctx.Response.StatusCode())
+		a.Equal("application/problem+json", string(ctx.Response.Header.ContentType()))
+		a.Equal(&http_problem.Problem{
+			Title:       "not found",
+			Status:      fasthttp.StatusNotFound,
+			Detail:      "record 1 does not exist",
+			Instance:    "/records/1",
+			Code:        42,
+			Kind:        "NotFoundError",
+			ServiceName: "example",
+		}, problem)
+	})
+
+	t.Run("should hide details", func(t *testing.T) {
+		_, problem := handle(&Server{hideErrorDetails: true}, serviceErr)
+		a.Empty(problem.Detail)
+	})
+
+	t.Run("should write other errors as internal ones", func(t *testing.T) {
+		ctx, problem := handle(&Server{}, errors.New("connection refused"))
+		a.Equal(fasthttp.StatusInternalServerError, ctx.Response.StatusCode())
+		a.Equal("InternalError", problem.Kind)
+		a.Equal("connection refused", problem.Detail)
+	})
+}
@@ -55,5 +55,11 @@ func (s *Server) Run(_ context.Context, srv interface{}) error {
 
 func (s *Server) Stop(ctx context.Context) error {
 	s.cancel()
+
+	// The service may be stopped before running, when its start fails.
+	if s.svc == nil {
+		return nil
+	}
+
 	return s.svc.Cleanup(ctx)
 }
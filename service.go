@@ -2,19 +2,24 @@ package mikros
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
-	"flag"
 	"fmt"
 	"log"
+	"net"
 	"os"
 	"os/signal"
 	"path/filepath"
 	"reflect"
 	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	errorsApi "github.com/somatech1/mikros/apis/errors"
+	healthApi "github.com/somatech1/mikros/apis/health"
 	loggerApi "github.com/somatech1/mikros/apis/logger"
+	secretsApi "github.com/somatech1/mikros/apis/secrets"
 	mcontext "github.com/somatech1/mikros/components/context"
 	"github.com/somatech1/mikros/components/definition"
 	mgrpc "github.com/somatech1/mikros/components/grpc"
@@ -24,8 +29,11 @@ import (
 	"github.com/somatech1/mikros/components/service"
 	"github.com/somatech1/mikros/components/testing"
 	merrors "github.com/somatech1/mikros/internal/components/errors"
+	"github.com/somatech1/mikros/internal/components/health"
 	"github.com/somatech1/mikros/internal/components/lifecycle"
+	"github.com/somatech1/mikros/internal/components/listener"
 	mlogger "github.com/somatech1/mikros/internal/components/logger"
+	"github.com/somatech1/mikros/internal/components/secrets"
 	"github.com/somatech1/mikros/internal/components/tags"
 	"github.com/somatech1/mikros/internal/components/tracker"
 	"github.com/somatech1/mikros/internal/components/validations"
@@ -36,6 +44,11 @@ import (
 	"github.com/somatech1/mikros/internal/services/script"
 )
 
+// ErrTestMode is given by Run when the service is in test mode, where it is
+// only initialized, without serving. Services that must run inside tests,
+// like in integration tests, must use the options.TestModeDisabled option.
+var ErrTestMode = errors.New("service is in test mode and was initialized without running")
+
 // Service is the object which represents a service application.
 type Service struct {
 	serviceToml     string
@@ -51,6 +64,19 @@ type Service struct {
 	features        *plugin.FeatureSet
 	services        *plugin.ServiceSet
 	tracker         *tracker.Tracker
+	health          *health.Health
+	secrets         *secrets.Secrets
+	flags           *commandLineFlags
+	environmentErr  error
+	customDefs      interface{}
+	buildInfo       *service.BuildInfo
+	addresses       map[string]net.Addr
+	addressesMu     sync.RWMutex
+
+	// featuresStarted and lifecycleStarted tell which start steps were
+	// executed and must be undone when the service stops.
+	featuresStarted  bool
+	lifecycleStarted bool
 }
 
 // ServiceName is the way to retrieve a service name from a string.
@@ -62,13 +88,9 @@ func ServiceName(name string) service.Name {
 // a new application.
 //
 // We don't return an error here to force the application to end in case
-// something wrong happens.
+// something wrong happens. Use New when an error is preferred.
 func NewService(opt *options.NewServiceOptions) *Service {
-	if err := opt.Validate(); err != nil {
-		log.Fatal(err)
-	}
-
-	svc, err := initService(opt)
+	svc, err := New(opt)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -76,40 +98,138 @@ func NewService(opt *options.NewServiceOptions) *Service {
 	return svc
 }
 
+// New creates a new Service object for building and putting to run a new
+// application, returning an error if it could not be created. It allows a
+// service to be created inside tests or other programs, where terminating
+// the process is not desired.
+func New(opt *options.NewServiceOptions) (*Service, error) {
+	if err := opt.Validate(); err != nil {
+		return nil, err
+	}
+
+	return initService(opt)
+}
+
 // initService parses the service.toml file and creates the Service object
 // initializing its main fields.
 func initService(opt *options.NewServiceOptions) (*Service, error) {
-	path, err := getServiceTomlPath()
+	cmdFlags := &commandLineFlags{}
+	if !opt.DisableConfigFlag {
+		cmdFlags = parseFlags()
+	}
+
+	if cmdFlags.printSchema || cmdFlags.validateConfig {
+		return initDefinitionsService(opt, cmdFlags)
+	}
+
+	defs, path, err := loadDefinitions(opt, cmdFlags)
 	if err != nil {
 		return nil, err
 	}
 
-	defs, err := definition.Parse(path)
+	// Loads environment variables
+	envs, err := loadEnvs(defs, opt.TestMode)
 	if err != nil {
 		return nil, err
 	}
 
-	// Loads environment variables
-	envs, err := loadEnvs(defs)
+	// Applies the definitions overlays, since they depend on the deployment
+	// environment, and reloads the environment variables that they may have
+	// changed.
+	overlays, err := loadDefinitionsOverlays(path, envs.DeploymentEnvName, cmdFlags)
 	if err != nil {
 		return nil, err
 	}
 
+	if len(overlays) > 0 {
+		defs, err = defs.Merge(overlays...)
+		if err != nil {
+			return nil, err
+		}
+
+		envs, err = loadEnvs(defs, opt.TestMode)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Only checked here because the deployment environment may be declared
+	// by an overlay.
+	if err := envs.loadDeploymentTraits(defs); err != nil {
+		return nil, err
+	}
+
+	return newService(opt, cmdFlags, defs, path, envs)
+}
+
+// initDefinitionsService creates a Service for the command line modes that
+// only handle its definitions. They must work without the service
+// environment, like inside CI pipelines, so missing environment variables
+// and an unknown deployment environment do not fail, they are kept to be
+// reported by the definitions validation.
+func initDefinitionsService(opt *options.NewServiceOptions, cmdFlags *commandLineFlags) (*Service, error) {
+	defs, path, err := loadDefinitions(opt, cmdFlags, definition.WithMissingEnvs())
+	if err != nil {
+		return nil, err
+	}
+
+	envs, envsErr := loadEnvs(defs, opt.TestMode)
+	overlays, err := loadDefinitionsOverlays(path, envs.DeploymentEnvName, cmdFlags)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(overlays) > 0 {
+		defs, err = defs.Merge(overlays...)
+		if err != nil {
+			return nil, err
+		}
+
+		envs, envsErr = loadEnvs(defs, opt.TestMode)
+	}
+
+	traitsErr := envs.loadDeploymentTraits(defs)
+
+	svc, err := newService(opt, cmdFlags, defs, path, envs)
+	if err != nil {
+		return nil, err
+	}
+
+	svc.environmentErr = errors.Join(defs.MissingEnvs(), envsErr, traitsErr)
+	return svc, nil
+}
+
+// newService creates the Service object from its loaded definitions and
+// environment variables, initializing its main fields.
+func newService(opt *options.NewServiceOptions, cmdFlags *commandLineFlags, defs *definition.Definitions, path string, envs *Env) (*Service, error) {
 	// Initialize the service logger system.
+	fixedAttributes := map[string]string{
+		"service.name":    defs.ServiceName().String(),
+		"service.type":    defs.ServiceTypesAsString(),
+		"service.version": defs.Version,
+		"service.env":     envs.DeploymentEnvName,
+		"service.product": defs.Product,
+	}
+
+	buildInfo, _ := service.ReadBuildInfo()
+	if buildInfo != nil {
+		for k, v := range buildInfo.Attributes() {
+			fixedAttributes[k] = v
+		}
+	}
+
 	serviceLogger := mlogger.New(mlogger.Options{
-		LogOnlyFatalLevel:      envs.DeploymentEnv == definition.ServiceDeploy_Test,
+		LogOnlyFatalLevel:      envs.DeploymentEnv == definition.ServiceDeploy_Test && !defs.Tests.EnableLogs,
 		DisableErrorStacktrace: !defs.Log.ErrorStacktrace,
-		FixedAttributes: map[string]string{
-			"service.name":    defs.ServiceName().String(),
-			"service.type":    defs.ServiceTypesAsString(),
-			"service.version": defs.Version,
-			"service.env":     envs.DeploymentEnv.String(),
-			"service.product": defs.Product,
-		},
+		FixedAttributes:        fixedAttributes,
 	})
 
-	if defs.Log.Level != "" {
-		if _, err := serviceLogger.SetLogLevel(defs.Log.Level); err != nil {
+	logLevel := defs.Log.Level
+	if logLevel == "" {
+		logLevel = envs.traits.LogLevel
+	}
+	if logLevel != "" {
+		if _, err := serviceLogger.SetLogLevel(logLevel); err != nil {
 			return nil, err
 		}
 	}
@@ -122,9 +242,11 @@ func initService(opt *options.NewServiceOptions) (*Service, error) {
 		return nil, err
 	}
 
+	features := registerInternalFeatures()
+
 	return &Service{
 		logger:          serviceLogger,
-		errors:          initServiceErrors(defs, serviceLogger),
+		errors:          initServiceErrors(defs, envs, serviceLogger),
 		clients:         opt.GrpcClients,
 		envs:            envs,
 		definitions:     defs,
@@ -132,17 +254,45 @@ func initService(opt *options.NewServiceOptions) (*Service, error) {
 		serviceOptions:  opt.Service,
 		ctx:             ctx,
 		serviceToml:     path,
-		features:        registerInternalFeatures(),
+		features:        features,
 		services:        registerInternalServices(),
+		health:          health.New(features),
+		secrets:         secrets.New(envs.SecretsPath),
+		flags:           cmdFlags,
+		customDefs:      opt.CustomDefinitions,
+		buildInfo:       buildInfo,
 	}, nil
 }
 
-func getServiceTomlPath() (string, error) {
-	path := flag.String("config", "", "Sets the alternative path for 'service.toml' file.")
-	flag.Parse()
+// loadDefinitions loads the service definitions from the source chosen by the
+// service options. It also gives back the 'service.toml' path when they were
+// loaded from a file.
+func loadDefinitions(opt *options.NewServiceOptions, cmdFlags *commandLineFlags, parseOpts ...definition.ParseOption) (*definition.Definitions, string, error) {
+	if opt.Definitions != nil {
+		return opt.Definitions, "", nil
+	}
+
+	if opt.DefinitionsSource != nil {
+		defs, err := definition.ParseReader(opt.DefinitionsSource, parseOpts...)
+		return defs, "", err
+	}
 
-	if path != nil && *path != "" {
-		return *path, nil
+	path, err := getServiceTomlPath(cmdFlags)
+	if err != nil {
+		return nil, "", err
+	}
+
+	defs, err := definition.Parse(path, parseOpts...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return defs, path, nil
+}
+
+func getServiceTomlPath(cmdFlags *commandLineFlags) (string, error) {
+	if cmdFlags.config != "" {
+		return cmdFlags.config, nil
 	}
 
 	serviceDir, err := os.Getwd()
@@ -153,10 +303,46 @@ func getServiceTomlPath() (string, error) {
 	return filepath.Join(serviceDir, "service.toml"), nil
 }
 
+// loadDefinitionsOverlays loads the overlays that must be merged on top of the
+// service definitions, in this order: the deployment environment file, which
+// must be located beside the 'service.toml' file with the environment name
+// as suffix, like 'service.prod.toml', and the file set by the
+// '-config-overlay' flag.
+func loadDefinitionsOverlays(path, env string, cmdFlags *commandLineFlags) ([]*definition.Overlay, error) {
+	var overlays []*definition.Overlay
+
+	if path != "" {
+		var (
+			ext     = filepath.Ext(path)
+			envPath = fmt.Sprintf("%s.%s%s", strings.TrimSuffix(path, ext), env, ext)
+		)
+
+		if _, err := os.Stat(envPath); err == nil {
+			overlay, err := definition.LoadOverlay(envPath)
+			if err != nil {
+				return nil, err
+			}
+
+			overlays = append(overlays, overlay)
+		}
+	}
+
+	if cmdFlags.configOverlay != "" {
+		overlay, err := definition.LoadOverlay(cmdFlags.configOverlay)
+		if err != nil {
+			return nil, err
+		}
+
+		overlays = append(overlays, overlay)
+	}
+
+	return overlays, nil
+}
+
 // loadEnvs loads the framework main environment variables through the env
 // feature plugin.
-func loadEnvs(defs *definition.Definitions) (*Env, error) {
-	return newEnv(defs)
+func loadEnvs(defs *definition.Definitions, testMode options.TestMode) (*Env, error) {
+	return newEnv(defs, testMode)
 }
 
 func registerInternalFeatures() *plugin.FeatureSet {
@@ -177,10 +363,14 @@ func registerInternalServices() *plugin.ServiceSet {
 	return services
 }
 
-func initServiceErrors(defs *definition.Definitions, log loggerApi.Logger) *merrors.Factory {
+func initServiceErrors(defs *definition.Definitions, envs *Env, log loggerApi.Logger) *merrors.Factory {
 	return merrors.NewFactory(merrors.FactoryOptions{
 		ServiceName: defs.ServiceName().String(),
 		Logger:      log,
+
+		// Stacks are always captured outside production-like environments
+		// so internal errors can be tracked down while developing.
+		CaptureStacktrace: defs.Log.ErrorStacktrace || !envs.traits.ProductionLike,
 	})
 }
 
@@ -202,34 +392,93 @@ func (s *Service) WithExternalFeatures(features *plugin.FeatureSet) *Service {
 	return s
 }
 
+// WithSecretProviders allows a service to add external secret providers into
+// it. They are searched, in the order they were added, before the built-in
+// providers, which load secrets from files and from environment variables.
+func (s *Service) WithSecretProviders(providers ...secretsApi.Provider) *Service {
+	s.secrets.Register(providers...)
+	return s
+}
+
 // Start puts the service in execution mode and blocks execution. This function
-// should be the last one called by the service.
+// should be the last one called by the service. It finishes the service when
+// a SIGTERM or SIGINT signal is received.
 //
 // We don't return an error here so that the service does not need to handle it
 // inside its code. We abort in case of an error.
 func (s *Service) Start(srv interface{}) {
-	ctx := context.Background()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	// Test mode is expected here, where the service is only initialized to
+	// be used by unit tests.
+	if err := s.Run(ctx, srv); err != nil && !errors.Is(err, ErrTestMode) {
+		var abortErr *merrors.AbortError
+		if !errors.As(err, &abortErr) {
+			abortErr = merrors.NewAbortError("fatal error", err)
+		}
+
+		s.abort(context.Background(), abortErr)
+	}
+}
+
+// Run puts the service in execution mode and blocks until ctx is canceled or
+// one of its servers fails. Differently from Start, it does not handle any
+// signal and returns an error instead of terminating the process, allowing
+// the service to be embedded inside other programs or integration tests.
+//
+// In test mode, the service is only initialized and ErrTestMode is returned,
+// since it is detected by default inside test binaries. Integration tests must
+// create the service with the options.TestModeDisabled option.
+func (s *Service) Run(ctx context.Context, srv interface{}) error {
+	// Command line modes that only handle the service definitions.
+	if s.flags.printSchema {
+		return s.printDefinitionsSchema()
+	}
+	if s.flags.validateConfig {
+		return s.validateConfig(ctx)
+	}
 
 	if err := s.start(ctx, srv); err != nil {
-		s.abort(ctx, err)
+		// Everything started before the failure must be released, so that
+		// the service can be started again by the same process.
+		s.releaseResources(context.WithoutCancel(ctx), srv)
+		return err
 	}
 
 	// If we're running tests, we end the method here to avoid putting the
-	// service in execution.
+	// service in execution, letting the caller know that it is not running.
 	if s.DeployEnvironment() == definition.ServiceDeploy_Test {
-		return
+		return ErrTestMode
 	}
 
-	s.run(ctx, srv)
+	if err := s.run(ctx, srv); err != nil {
+		return err
+	}
+
+	return nil
 }
 
 func (s *Service) start(ctx context.Context, srv interface{}) *merrors.AbortError {
-	s.logger.Info(ctx, "starting service")
+	s.logger.Info(ctx, "starting service",
+		logger.String("service.definitions", strings.Join(s.definitions.Layers(), ",")))
+
+	if err := s.resolveSecrets(ctx); err != nil {
+		return merrors.NewAbortError("could not resolve service secrets", err)
+	}
 
 	if err := s.validateDefinitions(); err != nil {
 		return merrors.NewAbortError("service definitions error", err)
 	}
 
+	// Plugins sections were all decoded while validating the definitions, so
+	// unknown keys can be checked before anything is started.
+	if err := s.checkUnknownDefinitions(ctx); err != nil {
+		return merrors.NewAbortError("service definitions error", err)
+	}
+
+	s.checkVersion(ctx)
+
 	if err := s.startFeatures(ctx, srv); err != nil {
 		return err
 	}
@@ -250,36 +499,337 @@ func (s *Service) start(ctx context.Context, srv interface{}) *merrors.AbortErro
 	return nil
 }
 
+// resolveSecrets replaces all secret references inside the service
+// definitions by their values, keeping track of the keys that hold them so
+// they can be masked.
+func (s *Service) resolveSecrets(ctx context.Context) error {
+	return s.definitions.ResolveSecrets(func(key, name string) (string, error) {
+		s.secrets.TrackKey(key)
+		return s.secrets.Resolve(ctx, name)
+	})
+}
+
 // validateDefinitions is responsible for validating the 'service.toml' file
 // content.
 //
 // It also adds all features and services (internal and external) settings into
 // the service definitions before validating it.
 func (s *Service) validateDefinitions() error {
+	var errs []error
+
+	iter := s.features.Iterator()
+	for p, next := iter.Next(); next; p, next = iter.Next() {
+		defs, err := s.loadFeatureDefinitions(p)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if defs != nil {
+			s.definitions.AddExternalFeatureDefinitions(p.Name(), defs)
+		}
+	}
+
+	for _, svc := range s.services.Services() {
+		defs, err := s.loadServiceDefinitions(svc)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if defs != nil {
+			s.definitions.AddExternalServiceDefinitions(svc.Name(), defs)
+		}
+	}
+
+	for _, instance := range s.definitions.ServiceInstances() {
+		defs, err := s.loadInstanceDefinitions(instance)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if defs != nil {
+			s.definitions.AddExternalServiceDefinitions(instance.Key(), defs)
+		}
+	}
+
+	if s.customDefs != nil {
+		if err := s.DecodeCustomDefinitions(s.customDefs); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := s.definitions.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if err := s.checkServiceAddresses(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// checkVersion warns, when enabled, if the service binary was built from a
+// module version different from the one in its definitions.
+func (s *Service) checkVersion(ctx context.Context) {
+	if !s.definitions.Validation.CheckVersion || s.buildInfo == nil || s.buildInfo.IsDevel() {
+		return
+	}
+
+	if !definition.EqualVersions(s.buildInfo.ModuleVersion, s.definitions.Version) {
+		s.logger.Warn(ctx, "service version differs from its module version",
+			logger.String("service.module_version", s.buildInfo.ModuleVersion))
+	}
+}
+
+// validateConfig validates the service definitions, including the ones from
+// all of its plugins, printing every error found.
+func (s *Service) validateConfig(ctx context.Context) error {
+	if err := s.definitionsErrors(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return errors.New("service definitions are not valid")
+	}
+
+	fmt.Fprintln(os.Stdout, "service definitions are valid")
+	return nil
+}
+
+// definitionsErrors gives every error found in the service definitions.
+// Environment variables and secrets that the definitions need, but are not
+// available, are reported as errors as well.
+func (s *Service) definitionsErrors(ctx context.Context) error {
+	err := errors.Join(s.environmentErr, s.resolveSecrets(ctx), s.validateDefinitions())
+	if s.definitions.Validation.Strict {
+		err = errors.Join(err, s.unknownDefinitionsKeys())
+	}
+
+	return err
+}
+
+// printDefinitionsSchema prints the JSON Schema of the service definitions,
+// including the definitions of all plugins. Plugins definitions types are
+// discovered without decoding the service definitions, so the schema does
+// not depend on them being valid. Plugins that load their definitions by
+// themselves have their sections described as free objects.
+func (s *Service) printDefinitionsSchema() error {
+	var (
+		errs     []error
+		sections = make(map[string]interface{})
+	)
+
 	iter := s.features.Iterator()
 	for p, next := iter.Next(); next; p, next = iter.Next() {
-		if cfg, ok := p.(plugin.FeatureSettings); ok {
-			defs, err := cfg.Definitions(s.serviceToml)
+		name := "features." + strings.TrimPrefix(p.Name(), options.FeatureNamePrefix)
+
+		if d, ok := p.(plugin.FeatureSettingsDecoder); ok {
+			defs, err := definitionsType(name, func(section *definition.Section) (interface{}, error) {
+				return d.DecodeDefinitions(section)
+			})
 			if err != nil {
-				return err
+				errs = append(errs, err)
+				continue
 			}
 
-			s.definitions.AddExternalFeatureDefinitions(p.Name(), defs)
+			sections[name] = defs
+			continue
+		}
+
+		if _, ok := p.(plugin.FeatureSettings); ok {
+			sections[name] = map[string]interface{}{}
 		}
 	}
 
 	for _, svc := range s.services.Services() {
-		if d, ok := svc.(plugin.ServiceSettings); ok {
-			defs, err := d.Definitions(s.serviceToml)
+		name := "services." + svc.Name()
+
+		if d, ok := svc.(plugin.ServiceSettingsDecoder); ok {
+			defs, err := definitionsType(name, func(section *definition.Section) (interface{}, error) {
+				return d.DecodeDefinitions(section)
+			})
 			if err != nil {
-				return err
+				errs = append(errs, err)
+				continue
 			}
 
-			s.definitions.AddExternalServiceDefinitions(svc.Name(), defs)
+			sections[name] = defs
+
+			// Named instances use the same definitions of their type.
+			for _, instance := range s.definitions.ServiceInstances() {
+				if instance.Type.String() == svc.Name() && instance.Name != "" {
+					sections[name+"."+instance.Name] = defs
+				}
+			}
+			continue
 		}
+
+		if _, ok := svc.(plugin.ServiceSettings); ok {
+			sections[name] = map[string]interface{}{}
+		}
+	}
+
+	if s.customDefs != nil {
+		sections["service"] = s.customDefs
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("could not generate the definitions schema: %w", err)
+	}
+
+	out, err := json.MarshalIndent(definition.GenerateSchema(sections), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stdout, string(out))
+	return nil
+}
+
+// definitionsType gives a value of the type that a plugin decodes its
+// definitions into, discovered by decoding a section without values.
+func definitionsType(name string, decode func(section *definition.Section) (interface{}, error)) (interface{}, error) {
+	section := definition.NewTypeSection(name)
+
+	// Plugins may fail to validate their empty definitions, which does not
+	// matter if their type was already known.
+	defs, err := decode(section)
+	if target := section.Target(); target != nil {
+		return target, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not discover '%s' definitions type: %w", name, err)
+	}
+	if defs == nil {
+		return nil, fmt.Errorf("could not discover '%s' definitions type", name)
 	}
 
-	return s.definitions.Validate()
+	return defs, nil
+}
+
+// loadFeatureDefinitions loads the feature custom settings, if it has support
+// for them.
+func (s *Service) loadFeatureDefinitions(p plugin.Feature) (definition.ExternalFeatureEntry, error) {
+	if cfg, ok := p.(plugin.FeatureSettingsDecoder); ok {
+		section, err := s.definitions.FeatureSection(strings.TrimPrefix(p.Name(), options.FeatureNamePrefix))
+		if err != nil {
+			return nil, err
+		}
+
+		return cfg.DecodeDefinitions(section)
+	}
+
+	if cfg, ok := p.(plugin.FeatureSettings); ok {
+		if s.serviceToml == "" {
+			return nil, fmt.Errorf("feature '%s' can only load its definitions from the 'service.toml' file", p.Name())
+		}
+
+		return cfg.Definitions(s.serviceToml)
+	}
+
+	return nil, nil
+}
+
+// loadServiceDefinitions loads the service custom settings, if it has support
+// for them.
+func (s *Service) loadServiceDefinitions(svc plugin.Service) (definition.ExternalServiceEntry, error) {
+	if d, ok := svc.(plugin.ServiceSettingsDecoder); ok {
+		section, err := s.definitions.ServiceSection(svc.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		return d.DecodeDefinitions(section)
+	}
+
+	if d, ok := svc.(plugin.ServiceSettings); ok {
+		if s.serviceToml == "" {
+			return nil, fmt.Errorf("service '%s' can only load its definitions from the 'service.toml' file", svc.Name())
+		}
+
+		return d.Definitions(s.serviceToml)
+	}
+
+	return nil, nil
+}
+
+// loadInstanceDefinitions loads the custom settings of a named service
+// instance, from its own [services.<type>.<name>] section, if its service
+// has support for them.
+func (s *Service) loadInstanceDefinitions(instance definition.ServiceInstance) (definition.ExternalServiceEntry, error) {
+	if instance.Name == "" {
+		return nil, nil
+	}
+
+	svc, ok := s.services.Services()[instance.Type.String()]
+	if !ok {
+		return nil, nil
+	}
+
+	d, ok := svc.(plugin.ServiceSettingsDecoder)
+	if !ok {
+		return nil, nil
+	}
+
+	section, err := s.definitions.InstanceSection(instance)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.DecodeDefinitions(section)
+}
+
+// checkUnknownDefinitions reports, when the strict validation is enabled,
+// every definitions key that is not used by the framework or by its plugins.
+// It fails in production-like environments and only warns otherwise.
+func (s *Service) checkUnknownDefinitions(ctx context.Context) error {
+	if !s.definitions.Validation.Strict {
+		return nil
+	}
+
+	err := s.unknownDefinitionsKeys()
+	if err == nil || s.envs.traits.ProductionLike {
+		return err
+	}
+
+	s.logger.Warn(ctx, "service definitions have unknown keys", logger.Error(err))
+	return nil
+}
+
+// unknownDefinitionsKeys gives an error listing all definitions keys that are
+// not used by the framework or by its plugins.
+func (s *Service) unknownDefinitionsKeys() error {
+	// Plugins that load their definitions by themselves are considered to use
+	// all of their section.
+	var used []string
+	iter := s.features.Iterator()
+	for p, next := iter.Next(); next; p, next = iter.Next() {
+		if _, ok := p.(plugin.FeatureSettingsDecoder); !ok {
+			if _, ok := p.(plugin.FeatureSettings); ok {
+				used = append(used, "features."+strings.TrimPrefix(p.Name(), options.FeatureNamePrefix))
+			}
+		}
+	}
+
+	for _, svc := range s.services.Services() {
+		if _, ok := svc.(plugin.ServiceSettingsDecoder); !ok {
+			if _, ok := svc.(plugin.ServiceSettings); ok {
+				used = append(used, "services."+svc.Name())
+			}
+		}
+	}
+
+	unknown := s.definitions.UnknownKeys(used...)
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	keys := make([]string, len(unknown))
+	for i, k := range unknown {
+		keys[i] = k.String()
+	}
+
+	return fmt.Errorf("unknown definitions keys: %s", strings.Join(keys, ", "))
 }
 
 // startFeatures starts all registered features and everything that are related
@@ -287,6 +837,9 @@ func (s *Service) validateDefinitions() error {
 func (s *Service) startFeatures(ctx context.Context, srv interface{}) *merrors.AbortError {
 	s.logger.Info(ctx, "starting dependent services")
 
+	// Features may be partially initialized when this fails, so they must
+	// be cleaned up anyway.
+	s.featuresStarted = true
 	// Initialize features
 	if err := s.initializeFeatures(ctx, srv); err != nil {
 		return merrors.NewAbortError("could not initialize features", err)
@@ -304,6 +857,7 @@ func (s *Service) initializeFeatures(ctx context.Context, srv interface{}) error
 		ServiceContext:  s.ctx,
 		RunTimeFeatures: s.runtimeFeatures,
 		Env:             s.envs.ToMapEnv(),
+		Secrets:         s.secrets,
 	}
 
 	// Initialize registered features
@@ -398,6 +952,7 @@ func (s *Service) initializeServiceInternals(ctx context.Context, srv interface{
 	}); err != nil {
 		return merrors.NewAbortError("failed while running lifecycle.OnStart", err)
 	}
+	s.lifecycleStarted = true
 
 	if s.envs.DeploymentEnv != definition.ServiceDeploy_Test {
 		if err := validations.EnsureValuesAreInitialized(srv); err != nil {
@@ -436,37 +991,55 @@ func (s *Service) initializeServiceHandler(srv interface{}) error {
 	return nil
 }
 
-func (s *Service) initializeRegisteredServices(ctx context.Context, srv interface{}) error {
-	getServicePort := func(port service.ServerPort, serviceType string) service.ServerPort {
-		// Use default port values in case no port was set in the service.toml
-		if port == 0 {
-			if serviceType == definition.ServiceType_gRPC.String() {
-				return service.ServerPort(s.envs.GrpcPort)
-			}
+func (s *Service) initializeRegisteredServices(ctx context.Context, srv interface{}) (err error) {
+	listeners, err := s.serviceListeners(ctx)
+	if err != nil {
+		return err
+	}
 
-			if serviceType == definition.ServiceType_HTTP.String() {
-				return service.ServerPort(s.envs.HttpPort)
+	// Listeners are owned by their servers once they are initialized, so
+	// only the remaining ones must be closed on failure.
+	defer func() {
+		if err != nil {
+			for _, l := range listeners {
+				_ = l.Close()
 			}
 		}
+	}()
 
-		return port
-	}
-
-	// Creates the service
-	for serviceType, servicePort := range s.definitions.ServiceTypes() {
+	// Creates the service instances, where the first instance of each type
+	// uses the registered service.
+	initialized := make(map[string]bool)
+	for _, instance := range s.definitions.ServiceInstances() {
+		serviceType := instance.Type
 		svc, ok := s.services.Services()[serviceType.String()]
 		if !ok {
 			return fmt.Errorf("could not find service implementation for '%v", serviceType.String())
 		}
 
-		opt, ok := s.serviceOptions[serviceType.String()]
+		if initialized[serviceType.String()] {
+			creator, ok := svc.(plugin.ServiceInstanceCreator)
+			if !ok {
+				return fmt.Errorf("service type '%v' does not support named instances", serviceType.String())
+			}
+
+			svc = creator.NewInstance()
+		}
+
+		// Named instances may have their own options, falling back to the
+		// ones of their type.
+		opt, ok := s.serviceOptions[instance.Key()]
+		if !ok {
+			opt, ok = s.serviceOptions[serviceType.String()]
+		}
 		if !ok {
-			return fmt.Errorf("could not find service type '%v' options in initialization", serviceType.String())
+			return fmt.Errorf("could not find service type '%v' options in initialization", instance.Key())
 		}
 
 		if err := svc.Initialize(ctx, &plugin.ServiceOptions{
-			Port:           getServicePort(servicePort, serviceType.String()),
+			Port:           s.servicePort(instance),
 			Type:           serviceType,
+			Instance:       instance.Name,
 			Name:           s.definitions.ServiceName(),
 			Product:        s.definitions.Product,
 			Logger:         s.logger,
@@ -478,23 +1051,178 @@ func (s *Service) initializeRegisteredServices(ctx context.Context, srv interfac
 			Features:       s.features,
 			ServiceHandler: srv,
 			Env:            s.envs.ToMapEnv(),
+			Health:         s.health,
+			BuildInfo:      s.buildInfo,
+			Listener:       listeners[instance.Key()],
+			Socket:         instance.Socket,
 		}); err != nil {
 			return err
 		}
 
 		// Saves only the initialized services
 		s.servers = append(s.servers, svc)
+		delete(listeners, instance.Key())
+		if l, ok := svc.(plugin.ServiceListener); ok {
+			s.setAddress(instance.Key(), l.Addr())
+		}
+		initialized[serviceType.String()] = true
 	}
 
 	return nil
 }
 
+// servicePort gives the server port of a service instance. Instances without
+// a port declared in the definitions use the default one of their type, and
+// port 0 lets the system choose a free port.
+func (s *Service) servicePort(instance definition.ServiceInstance) service.ServerPort {
+	if instance.HasPort {
+		return instance.Port
+	}
+
+	if instance.Type == definition.ServiceType_gRPC {
+		return service.ServerPort(s.envs.GrpcPort)
+	}
+
+	if instance.Type == definition.ServiceType_HTTP {
+		return service.ServerPort(s.envs.HttpPort)
+	}
+
+	return 0
+}
+
+// serviceListeners gives the listeners, by the instances keys, that service
+// instances must use instead of creating their own. They are the listeners
+// inherited from the process manager, named after the instances keys, with
+// '.' instead of ':', like 'http.public', and the listener shared by the
+// first gRPC and HTTP instances, when enabled.
+func (s *Service) serviceListeners(ctx context.Context) (map[string]net.Listener, error) {
+	inherited, err := listener.Inherited()
+	if err != nil {
+		return nil, err
+	}
+
+	listeners := make(map[string]net.Listener)
+	for _, instance := range s.definitions.ServiceInstances() {
+		name := strings.ReplaceAll(instance.Key(), ":", ".")
+		if l, ok := inherited[name]; ok {
+			listeners[instance.Key()] = l
+			delete(inherited, name)
+		}
+	}
+
+	for name, l := range inherited {
+		s.logger.Warn(ctx, "inherited listener does not match any service type", logger.String("listener.name", name))
+		_ = l.Close()
+	}
+
+	if s.definitions.Listener.Shared {
+		if err := s.shareListener(listeners); err != nil {
+			return nil, err
+		}
+	}
+
+	return listeners, nil
+}
+
+// shareListener replaces the listeners of the first gRPC and HTTP instances
+// by a single one, using the listener or the address of the first one
+// declared.
+func (s *Service) shareListener(listeners map[string]net.Listener) error {
+	first, second, err := s.sharedInstances()
+	if err != nil {
+		return err
+	}
+
+	root, ok := listeners[first.Key()]
+	if !ok {
+		l, err := listener.New(s.servicePort(first), first.Socket)
+		if err != nil {
+			return err
+		}
+		root = l
+	}
+
+	shared := listener.Share(root)
+	grpcKey, httpKey := first.Key(), second.Key()
+	if first.Type == definition.ServiceType_HTTP {
+		grpcKey, httpKey = httpKey, grpcKey
+	}
+	listeners[grpcKey] = shared.GRPC()
+	listeners[httpKey] = shared.HTTP()
+
+	return nil
+}
+
+// sharedInstances gives the first gRPC and HTTP instances, which share a
+// listener when enabled, ordered by their declaration.
+func (s *Service) sharedInstances() (first, second definition.ServiceInstance, err error) {
+	var found []definition.ServiceInstance
+	for _, instance := range s.definitions.ServiceInstances() {
+		if instance.Type != definition.ServiceType_gRPC && instance.Type != definition.ServiceType_HTTP {
+			continue
+		}
+
+		if len(found) == 0 || (len(found) == 1 && found[0].Type != instance.Type) {
+			found = append(found, instance)
+		}
+	}
+
+	if len(found) != 2 {
+		return first, second, errors.New("a shared listener requires both 'grpc' and 'http' service types")
+	}
+
+	return found[0], found[1], nil
+}
+
+// checkServiceAddresses ensures that service instances do not listen on the
+// same port or unix socket, which would only fail when their servers are
+// initialized. The second instance of a shared listener does not use its own
+// address, so it is not checked.
+func (s *Service) checkServiceAddresses() error {
+	var (
+		errs    []error
+		ignored string
+		used    = make(map[string]string)
+	)
+
+	if s.definitions.Listener.Shared {
+		if _, second, err := s.sharedInstances(); err == nil {
+			ignored = second.Key()
+		}
+	}
+
+	for _, instance := range s.definitions.ServiceInstances() {
+		if instance.Key() == ignored {
+			continue
+		}
+
+		address := "unix socket '" + instance.Socket + "'"
+		if instance.Socket == "" {
+			port := s.servicePort(instance)
+			if port == 0 {
+				continue
+			}
+
+			address = fmt.Sprintf("port %d", port)
+		}
+
+		if other, ok := used[address]; ok {
+			errs = append(errs, fmt.Errorf("service types '%s' and '%s' use the same %s", other, instance.Key(), address))
+			continue
+		}
+
+		used[address] = instance.Key()
+	}
+
+	return errors.Join(errs...)
+}
+
 // coupleClients establishes connections with all client services that a service
 // has as dependency.
 func (s *Service) coupleClients(srv interface{}) error {
-	// If the service does not have dependencies, or we are running tests,
-	// don't need to continue.
-	if len(s.clients) == 0 || s.envs.DeploymentEnv == definition.ServiceDeploy_Test {
+	// If the service does not have dependencies, or we are running tests
+	// without coupling them, don't need to continue.
+	if len(s.clients) == 0 || (s.envs.DeploymentEnv == definition.ServiceDeploy_Test && !s.definitions.Tests.CoupleClients) {
 		return nil
 	}
 
@@ -572,15 +1300,15 @@ func (s *Service) printServiceResources(ctx context.Context) {
 		fields = append(fields, f.Fields()...)
 	}
 
-	s.logger.Info(ctx, "service resources", fields...)
+	// Features may expose values that came from secrets, which must never be
+	// written.
+	s.logger.Info(ctx, "service resources", s.secrets.MaskAttributes(fields)...)
 }
 
-func (s *Service) run(ctx context.Context, srv interface{}) {
-	defer s.stopService(ctx)
-	defer lifecycle.OnFinish(srv, ctx, &lifecycle.LifecycleOptions{
-		Env:            s.DeployEnvironment(),
-		ExecuteOnTests: s.definitions.Tests.ExecuteLifecycle,
-	})
+func (s *Service) run(ctx context.Context, srv interface{}) *merrors.AbortError {
+	// The service must be stopped even if ctx was canceled, so its shutdown
+	// sequence cannot inherit the cancellation.
+	defer s.stopService(context.WithoutCancel(ctx), srv)
 
 	// In case we're a script service, only execute its function and terminate
 	// the execution.
@@ -589,19 +1317,14 @@ func (s *Service) run(ctx context.Context, srv interface{}) {
 		s.logger.Info(ctx, "service is running", svc.Info()...)
 
 		if err := svc.Run(ctx, srv); err != nil {
-			s.abort(ctx, merrors.NewAbortError("fatal error", err))
+			return merrors.NewAbortError("fatal error", err)
 		}
 
-		return
+		return nil
 	}
 
 	// Otherwise, initialize all service types and put them to run.
-
-	// Create channels for finishing the service and bind the signal that
-	// finishes it.
-	errChan := make(chan error)
-	stopChan := make(chan os.Signal, 1)
-	signal.Notify(stopChan, syscall.SIGTERM, syscall.SIGINT)
+	errChan := make(chan error, len(s.servers))
 
 	for _, svc := range s.servers {
 		go func(service plugin.Service) {
@@ -612,30 +1335,114 @@ func (s *Service) run(ctx context.Context, srv interface{}) {
 		}(svc)
 	}
 
+	// All servers are listening and lifecycle.OnStart has already succeeded,
+	// so the service is ready to receive requests.
+	s.health.SetState(healthApi.StateReady)
+	s.logger.Info(ctx, "service is ready")
+
 	// Blocks the call
 	select {
 	case err := <-errChan:
-		s.abort(ctx, merrors.NewAbortError("fatal error", err))
+		return merrors.NewAbortError("fatal error", err)
 
-	case <-stopChan:
+	case <-ctx.Done():
 	}
+
+	return nil
 }
 
-func (s *Service) stopService(ctx context.Context) {
+// stopService executes the service shutdown sequence. It first announces that
+// the service is not serving anymore, waits for the configured pre-stop delay,
+// and then stops all servers, waiting for their in-flight requests until the
+// shutdown deadline is reached.
+func (s *Service) stopService(ctx context.Context, srv interface{}) {
 	s.logger.Info(ctx, "stopping service")
 
-	if err := s.stopDependentServices(ctx); err != nil {
-		s.logger.Error(ctx, "could not stop other running services", logger.Error(err))
+	// Servers health checks answer that the service is not ready while it
+	// is draining.
+	s.health.SetState(healthApi.StateDraining)
+
+	if delay := s.definitions.Shutdown.PreStopDelay; delay > 0 && !s.definitions.IsServiceType(definition.ServiceType_Script) {
+		s.logger.Info(ctx, "waiting pre-stop delay", logger.String("shutdown.pre_stop_delay", delay.String()))
+		time.Sleep(delay)
 	}
 
-	for _, svc := range s.servers {
-		if err := svc.Stop(ctx); err != nil {
-			s.logger.Error(ctx, "could not stop service server",
-				append([]loggerApi.Attribute{logger.Error(err)}, svc.Info()...)...)
+	s.releaseResources(ctx, srv)
+	s.Logger().Info(ctx, "service stopped")
+}
+
+// releaseResources stops everything that the service started: its servers,
+// its lifecycle and its features.
+func (s *Service) releaseResources(ctx context.Context, srv interface{}) {
+	s.stopServers(ctx)
+	s.servers = nil
+
+	if s.lifecycleStarted {
+		lifecycle.OnFinish(srv, ctx, &lifecycle.LifecycleOptions{
+			Env:            s.DeployEnvironment(),
+			ExecuteOnTests: s.definitions.Tests.ExecuteLifecycle,
+		})
+		s.lifecycleStarted = false
+	}
+
+	if s.featuresStarted {
+		if err := s.stopDependentServices(ctx); err != nil {
+			s.logger.Error(ctx, "could not stop other running services", logger.Error(err))
 		}
+		s.featuresStarted = false
 	}
+}
 
-	s.Logger().Info(ctx, "service stopped")
+// stopServers stops all servers concurrently, giving them until the shutdown
+// timeout to finish. A zero timeout means that there is no deadline.
+func (s *Service) stopServers(ctx context.Context) {
+	var (
+		wg      sync.WaitGroup
+		stopCtx = ctx
+		cancel  = func() {}
+	)
+
+	if timeout := s.definitions.Shutdown.Timeout; timeout > 0 {
+		stopCtx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	defer cancel()
+
+	for _, svc := range s.servers {
+		wg.Add(1)
+		go func(server plugin.Service) {
+			defer wg.Done()
+			s.stopServer(stopCtx, server)
+		}(svc)
+	}
+
+	wg.Wait()
+}
+
+// stopServer stops a server, which must honour ctx, forcing itself to stop
+// when its deadline is reached.
+func (s *Service) stopServer(ctx context.Context, svc plugin.Service) {
+	start := time.Now()
+
+	err := svc.Stop(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		s.logger.Warn(ctx, "service server forced to stop after shutdown deadline",
+			append([]loggerApi.Attribute{elapsedAttribute(start)}, svc.Info()...)...)
+		return
+	}
+
+	if err != nil {
+		s.logger.Error(ctx, "could not stop service server",
+			append([]loggerApi.Attribute{logger.Error(err)}, svc.Info()...)...)
+		return
+	}
+
+	s.logger.Info(ctx, "service server stopped",
+		append([]loggerApi.Attribute{elapsedAttribute(start)}, svc.Info()...)...)
+}
+
+// elapsedAttribute gives a log attribute with the time elapsed since start.
+func elapsedAttribute(start time.Time) loggerApi.Attribute {
+	return logger.String("shutdown.elapsed", time.Since(start).String())
 }
 
 // stopDependentServices stops other services that are running along with the
@@ -643,10 +1450,12 @@ func (s *Service) stopService(ctx context.Context) {
 func (s *Service) stopDependentServices(ctx context.Context) error {
 	s.logger.Info(ctx, "stopping dependent services")
 
+	start := time.Now()
 	if err := s.features.CleanupAll(ctx); err != nil {
 		return err
 	}
 
+	s.logger.Info(ctx, "dependent services stopped", elapsedAttribute(start))
 	return nil
 }
 
@@ -682,6 +1491,12 @@ func (s *Service) DeployEnvironment() definition.ServiceDeploy {
 	return s.envs.DeploymentEnv
 }
 
+// DeployEnvironmentName gives the name of the current service deployment
+// environment, including the ones declared inside the [environments] section.
+func (s *Service) DeployEnvironmentName() string {
+	return s.envs.DeploymentEnvName
+}
+
 // tags gives a map of current service tags to be used with external resources.
 func (s *Service) tags() map[string]string {
 	serviceType := s.definitions.ServiceTypesAsString()
@@ -760,6 +1575,53 @@ func (s *Service) SetupTest(ctx context.Context, t *testing.Testing) *ServiceTes
 	return setupServiceTesting(ctx, s, t)
 }
 
+// Definitions gives access to the service definitions, already merged with
+// all of its overlays.
+func (s *Service) Definitions() *definition.Definitions {
+	return s.definitions
+}
+
+// DecodeCustomDefinitions decodes the service custom settings, i.e., the
+// [service] section of the definitions, into target, which must be a pointer
+// to a struct using 'toml' tags. Its default values are set using 'default'
+// tags and it is validated using 'validate' tags.
+func (s *Service) DecodeCustomDefinitions(target interface{}) error {
+	section, err := s.definitions.CustomSection()
+	if err != nil {
+		return err
+	}
+
+	return section.Decode(target)
+}
+
+// Addresses gives the addresses where the service servers are listening for
+// connections, using as key their service type, or 'type:name' for named
+// instances. Addresses are only available after the servers are initialized,
+// and they are the real ones, even when a server uses port 0 to have a free
+// port chosen for it.
+func (s *Service) Addresses() map[string]net.Addr {
+	s.addressesMu.RLock()
+	defer s.addressesMu.RUnlock()
+
+	addresses := make(map[string]net.Addr, len(s.addresses))
+	for k, v := range s.addresses {
+		addresses[k] = v
+	}
+
+	return addresses
+}
+
+func (s *Service) setAddress(key string, addr net.Addr) {
+	s.addressesMu.Lock()
+	defer s.addressesMu.Unlock()
+
+	if s.addresses == nil {
+		s.addresses = make(map[string]net.Addr)
+	}
+
+	s.addresses[key] = addr
+}
+
 // CustomDefinitions gives the service access to the service custom settings
 // that it may have put inside the 'service.toml' file.
 //
@@ -0,0 +1,293 @@
+package mikros
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+
+	loggerApi "github.com/somatech1/mikros/apis/logger"
+	"github.com/somatech1/mikros/components/definition"
+	"github.com/somatech1/mikros/components/options"
+	"github.com/somatech1/mikros/components/plugin"
+)
+
+const runTestDefinitions = `
+name = "example"
+types = ["grpc:0"]
+version = "v1.0.0"
+language = "go"
+product = "mikros"
+
+[log]
+level = "error"
+`
+
+type runTestService struct {
+	*Service
+	onStartErr error `mikros:"skip"`
+	finished   bool  `mikros:"skip"`
+}
+
+func (s *runTestService) OnStart(_ context.Context) error {
+	return s.onStartErr
+}
+
+func (s *runTestService) OnFinish(_ context.Context) {
+	s.finished = true
+}
+
+type runTestFeature struct {
+	plugin.Entry
+	cleaned bool
+}
+
+func (f *runTestFeature) CanBeInitialized(_ *plugin.CanBeInitializedOptions) bool {
+	return true
+}
+
+func (f *runTestFeature) Initialize(_ context.Context, _ *plugin.InitializeOptions) error {
+	return nil
+}
+
+func (f *runTestFeature) Fields() []loggerApi.Attribute {
+	return nil
+}
+
+func (f *runTestFeature) Start(_ context.Context, _ interface{}) error {
+	return nil
+}
+
+func (f *runTestFeature) Cleanup(_ context.Context) error {
+	f.cleaned = true
+	return nil
+}
+
+func newRunTestService(t *testing.T) (*Service, *runTestFeature) {
+	return newRunTestServiceMode(t, options.TestModeDisabled)
+}
+
+func newRunTestServiceMode(t *testing.T, testMode options.TestMode) (*Service, *runTestFeature) {
+	svc, err := New(&options.NewServiceOptions{
+		Service: map[string]options.ServiceOptions{
+			"grpc": &options.GrpcServiceOptions{
+				ProtoServiceDescription: &grpc.ServiceDesc{
+					ServiceName: "example.Service",
+					HandlerType: (*interface{})(nil),
+				},
+			},
+		},
+		DefinitionsSource: strings.NewReader(runTestDefinitions),
+		TestMode:          testMode,
+		DisableConfigFlag: true,
+	})
+	assert.NoError(t, err)
+
+	feature := &runTestFeature{}
+	features := plugin.NewFeatureSet()
+	features.Register("run_test", feature)
+	svc.WithExternalFeatures(features)
+
+	return svc, feature
+}
+
+// assertReleased checks that the address is not being used anymore.
+func assertReleased(t *testing.T, addr net.Addr) {
+	l, err := net.Listen(addr.Network(), addr.String())
+	if assert.NoError(t, err) {
+		_ = l.Close()
+	}
+}
+
+func TestServiceRun(t *testing.T) {
+	a := assert.New(t)
+	t.Setenv("MIKROS_SERVICE_DEPLOY", "local")
+
+	t.Run("should return when the context is canceled", func(t *testing.T) {
+		svc, feature := newRunTestService(t)
+		srv := &runTestService{}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			done <- svc.Run(ctx, srv)
+		}()
+
+		var addr net.Addr
+		a.Eventually(func() bool {
+			addr = svc.Addresses()["grpc"]
+			return addr != nil
+		}, 5*time.Second, 10*time.Millisecond)
+
+		cancel()
+		select {
+		case err := <-done:
+			a.NoError(err)
+		case <-time.After(5 * time.Second):
+			a.Fail("service did not stop after the context was canceled")
+		}
+
+		a.True(srv.finished)
+		a.True(feature.cleaned)
+		assertReleased(t, addr)
+	})
+
+	t.Run("should release everything when the start fails", func(t *testing.T) {
+		svc, feature := newRunTestService(t)
+		srv := &runTestService{onStartErr: errors.New("database unavailable")}
+
+		err := svc.Run(context.Background(), srv)
+		a.ErrorContains(err, "database unavailable")
+
+		// OnStart failed, so there is nothing to finish.
+		a.False(srv.finished)
+		a.True(feature.cleaned)
+		assertReleased(t, svc.Addresses()["grpc"])
+	})
+
+	t.Run("should tell that it is not running in test mode", func(t *testing.T) {
+		// Test mode is detected inside test binaries by default.
+		svc, _ := newRunTestServiceMode(t, options.TestModeAuto)
+		srv := &runTestService{}
+
+		err := svc.Run(context.Background(), srv)
+		a.ErrorIs(err, ErrTestMode)
+
+		svc.releaseResources(context.Background(), srv)
+	})
+}
+
+func TestDefinitionsType(t *testing.T) {
+	a := assert.New(t)
+
+	type example struct {
+		Host string `toml:"host" validate:"required"`
+	}
+
+	t.Run("should give the decoded type even if its validation fails", func(t *testing.T) {
+		defs, err := definitionsType("features.example", func(section *definition.Section) (interface{}, error) {
+			var e example
+			if err := section.Decode(&e); err != nil {
+				return nil, err
+			}
+
+			return nil, errors.New("host is empty")
+		})
+		a.NoError(err)
+		a.IsType(&example{}, defs)
+	})
+
+	t.Run("should fail when the type is unknown", func(t *testing.T) {
+		_, err := definitionsType("features.example", func(_ *definition.Section) (interface{}, error) {
+			return nil, errors.New("could not read file")
+		})
+		a.ErrorContains(err, "could not discover 'features.example' definitions type")
+	})
+}
+
+func TestCheckServiceAddresses(t *testing.T) {
+	a := assert.New(t)
+	t.Setenv("MIKROS_SERVICE_DEPLOY", "local")
+	t.Setenv("MIKROS_GRPC_PORT", "7070")
+	t.Setenv("MIKROS_HTTP_PORT", "8080")
+
+	newService := func(types, listener string) *Service {
+		svc, err := New(&options.NewServiceOptions{
+			Service: map[string]options.ServiceOptions{
+				"grpc": &options.GrpcServiceOptions{},
+				"http": &options.HttpServiceOptions{},
+			},
+			DefinitionsSource: strings.NewReader(`
+name = "example"
+types = ` + types + `
+version = "v1.0.0"
+language = "go"
+product = "mikros"
+` + listener),
+			TestMode:          options.TestModeDisabled,
+			DisableConfigFlag: true,
+		})
+		a.NoError(err)
+
+		return svc
+	}
+
+	t.Run("should reject instances using the same default port", func(t *testing.T) {
+		svc := newService(`["grpc", "grpc:internal"]`, "")
+		a.EqualError(svc.checkServiceAddresses(), "service types 'grpc' and 'grpc:internal' use the same port 7070")
+
+		svc = newService(`["http", "http:public"]`, "")
+		a.EqualError(svc.checkServiceAddresses(), "service types 'http' and 'http:public' use the same port 8080")
+	})
+
+	t.Run("should reject instances using the same port", func(t *testing.T) {
+		svc := newService(`["grpc:9090", "http:9090"]`, "")
+		a.EqualError(svc.checkServiceAddresses(), "service types 'grpc' and 'http' use the same port 9090")
+	})
+
+	t.Run("should accept instances sharing a listener", func(t *testing.T) {
+		svc := newService(`["grpc:9090", "http:9090"]`, "[listener]\nshared = true\n")
+		a.NoError(svc.checkServiceAddresses())
+	})
+
+	t.Run("should accept free ports", func(t *testing.T) {
+		svc := newService(`["grpc:0", "grpc:internal:0"]`, "")
+		a.NoError(svc.checkServiceAddresses())
+	})
+}
+
+func TestDefinitionsModes(t *testing.T) {
+	a := assert.New(t)
+	t.Setenv("MIKROS_SERVICE_DEPLOY", "staging")
+
+	newService := func(t *testing.T, flags *commandLineFlags) *Service {
+		svc, err := initDefinitionsService(&options.NewServiceOptions{
+			Service: map[string]options.ServiceOptions{
+				"grpc": &options.GrpcServiceOptions{},
+			},
+			DefinitionsSource: strings.NewReader(`
+name = "example"
+types = ["grpc"]
+version = "v1.0.0"
+language = "go"
+product = "mikros"
+envs = ["MIKROS_TEST_MISSING_ENV"]
+
+[service]
+host = "${MIKROS_TEST_MISSING_HOST}"
+password = "secret://mikros-test-missing-password"
+`),
+			TestMode:          options.TestModeDisabled,
+			DisableConfigFlag: true,
+		}, flags)
+		assert.NoError(t, err)
+
+		return svc
+	}
+
+	t.Run("should not need the service environment", func(t *testing.T) {
+		svc := newService(t, &commandLineFlags{printSchema: true})
+		a.NoError(svc.printDefinitionsSchema())
+	})
+
+	t.Run("should report the missing environment as validation errors", func(t *testing.T) {
+		svc := newService(t, &commandLineFlags{validateConfig: true})
+
+		err := svc.definitionsErrors(context.Background())
+		a.ErrorContains(err, "'MIKROS_TEST_MISSING_HOST' (used by service.host)")
+		a.ErrorContains(err, "environment variable 'MIKROS_TEST_MISSING_ENV' must be set")
+		a.ErrorContains(err, "deployment environment 'staging' must be declared")
+		a.ErrorContains(err, "'mikros-test-missing-password' (used by service.password)")
+	})
+
+	t.Run("should keep custom environments by name", func(t *testing.T) {
+		svc := newService(t, &commandLineFlags{validateConfig: true})
+		a.Equal(definition.ServiceDeploy_Unknown, svc.DeployEnvironment())
+		a.Equal("staging", svc.DeployEnvironmentName())
+	})
+}